@@ -2,29 +2,275 @@ package config
 
 import (
 	"encoding/json"
+	"fmt"
+	"net/url"
 	"os"
+	"strconv"
+	"strings"
 	"time"
+
+	"regression_testing/internal/model"
 )
 
 // 添加一个辅助结构体来处理 JSON 解析
 type jsonConfig struct {
-	ExcelPath     string `json:"excel_path"`
-	SheetName     string `json:"sheet_name"`
-	HeaderRow     int    `json:"header_row"`
-	BaseURL       string `json:"base_url"`
-	Authorization string `json:"authorization"`
-	Timeout       string `json:"timeout"` // 改为 string 类型
-	Concurrent    int    `json:"concurrent"`
+	ExcelPath     string                `json:"excel_path"`
+	SheetName     string                `json:"sheet_name"`
+	HeaderRow     int                   `json:"header_row"`
+	BaseURL       string                `json:"base_url"`
+	Authorization string                `json:"authorization"`
+	Timeout       string                `json:"timeout"` // 改为 string 类型
+	Concurrent    int                   `json:"concurrent"`
+	Sheets        []string              `json:"sheets"`
+	HooksSheet    string                `json:"hooks_sheet"`
+	Retry         jsonRetry             `json:"retry"`
+	RateLimit     RateLimit             `json:"rate_limit"`
+	FailFast      bool                  `json:"fail_fast"`
+	MaxFailures   int                   `json:"max_failures"`
+	Filter        Filter                `json:"filter"`
+	SlowThreshold string                `json:"slow_threshold"`
+	Transport     jsonTransport         `json:"transport"`
+	TLS           TLS                   `json:"tls"`
+	Proxy         Proxy                 `json:"proxy"`
+	Resolve       map[string]string     `json:"resolve"`
+	Targets       map[string]jsonTarget `json:"targets"`
+}
+
+// jsonRetry 是重试策略在配置文件和用例表中的 JSON 格式
+type jsonRetry struct {
+	MaxAttempts int      `json:"max_attempts"`
+	Backoff     string   `json:"backoff"`
+	MaxBackoff  string   `json:"max_backoff"`
+	On          []string `json:"on"`
+}
+
+// policy 转换为重试策略，无法解析的时间保持零值
+func (j jsonRetry) policy() model.RetryPolicy {
+	backoff, _ := time.ParseDuration(j.Backoff)
+	maxBackoff, _ := time.ParseDuration(j.MaxBackoff)
+	return model.RetryPolicy{
+		MaxAttempts: j.MaxAttempts,
+		Backoff:     backoff,
+		MaxBackoff:  maxBackoff,
+		On:          j.On,
+	}
+}
+
+// ParseRetryPolicy 解析用例表中的重试策略，未填写的字段保持零值
+func ParseRetryPolicy(data string) (model.RetryPolicy, error) {
+	var j jsonRetry
+	if err := json.Unmarshal([]byte(data), &j); err != nil {
+		return model.RetryPolicy{}, err
+	}
+	return j.policy(), nil
 }
 
 type Config struct {
-	ExcelPath     string
-	SheetName     string
-	HeaderRow     int
-	BaseURL       string
-	Authorization string
-	Timeout       time.Duration
-	Concurrent    int
+	ExcelPath        string
+	SheetName        string
+	HeaderRow        int
+	BaseURL          string
+	Authorization    string
+	Timeout          time.Duration
+	Concurrent       int
+	Sheets           []string // 需要执行的工作表，为空时只执行 SheetName
+	HooksSheet       string   // 前置/后置钩子所在的工作表
+	Retry            model.RetryPolicy
+	RateLimit        RateLimit
+	FailFast         bool // 首个用例失败后停止执行
+	MaxFailures      int  // 失败用例数达到该值后停止执行，0 表示不限制
+	Filter           Filter
+	SlowThreshold    time.Duration     // 报告中标记为慢请求的耗时阈值
+	Transport        Transport         // 默认连接池配置
+	TLS              TLS               // 默认 TLS 配置
+	Proxy            Proxy             // 默认代理，未配置时使用环境变量 HTTP_PROXY / HTTPS_PROXY / NO_PROXY
+	Resolve          map[string]string // 将 host:port（或主机名的所有端口）固定解析到指定的 IP:port，Host 请求头和 TLS SNI 保持不变
+	Targets          map[string]Target // 按被测服务（scheme://host:port）覆盖的配置
+	Shard            Shard             // 只执行指定分片的用例，由命令行参数 --shard 设置
+	Repeat           int               // 重复执行次数，由命令行参数 --repeat 设置
+	RepeatConcurrent bool              // 重复执行的各份副本一起并发执行，而不是逐次执行
+	Shuffle          bool              // 打乱用例的执行顺序
+	Seed             int64             // 打乱顺序的随机种子，0 表示使用当前时间
+	LoadTest         LoadTest          // 压测参数，由命令行参数设置
+}
+
+// LoadTest 压测参数：按目标速率或并发数在指定时长内循环发送用例
+type LoadTest struct {
+	Duration    time.Duration // 压测时长，0 表示不压测
+	RPS         float64       // 所有用例合计的目标每秒请求数，0 表示不限制速率
+	Concurrency int           // 并发数，0 表示使用 concurrent
+}
+
+// Enabled 判断是否为压测模式
+func (l LoadTest) Enabled() bool {
+	return l.Duration > 0
+}
+
+// Shard 表示 n 个分片中的第 i 个（从 1 开始），零值表示不分片
+type Shard struct {
+	Index int
+	Total int
+}
+
+// ParseShard 解析 i/n 格式的分片，eg: 1/3
+func ParseShard(value string) (Shard, error) {
+	i, n, ok := strings.Cut(value, "/")
+	index, err1 := strconv.Atoi(strings.TrimSpace(i))
+	total, err2 := strconv.Atoi(strings.TrimSpace(n))
+	if !ok || err1 != nil || err2 != nil || total < 1 || index < 1 || index > total {
+		return Shard{}, fmt.Errorf("分片格式无效: %s，应为 i/n 且 1 <= i <= n", value)
+	}
+	return Shard{Index: index, Total: total}, nil
+}
+
+// Enabled 判断是否需要分片
+func (s Shard) Enabled() bool {
+	return s.Total > 0
+}
+
+func (s Shard) String() string {
+	return fmt.Sprintf("%d/%d", s.Index, s.Total)
+}
+
+// Target 返回 origin 对应的被测服务配置，未单独配置时使用全局配置
+func (c *Config) Target(origin string) Target {
+	if t, ok := c.Targets[origin]; ok {
+		return t
+	}
+	return Target{Transport: c.Transport, TLS: c.TLS, Proxy: c.Proxy}
+}
+
+// Filter 用例筛选条件，未设置的条件不生效
+type Filter struct {
+	Tags        []string `json:"tags"`         // 包含任一标签的用例
+	ExcludeTags []string `json:"exclude_tags"` // 排除包含任一标签的用例
+	Name        string   `json:"name"`         // 用例名称正则
+	Rows        string   `json:"rows"`         // 行号范围，eg: 10-40,50
+	Priorities  []string `json:"priority"`     // 优先级，eg: P0
+}
+
+// Transport 连接池配置，同一被测服务的请求共享连接
+type Transport struct {
+	MaxIdleConns        int           // 所有主机的最大空闲连接数
+	MaxIdleConnsPerHost int           // 每个主机的最大空闲连接数，0 表示与并发数一致
+	MaxConnsPerHost     int           // 每个主机的最大连接数，0 表示不限制
+	IdleConnTimeout     time.Duration // 空闲连接的保留时间
+	KeepAlive           time.Duration // TCP keep-alive 探测间隔
+	DisableKeepAlives   bool          // 每个请求都新建连接
+	H2C                 bool          // 使用明文 HTTP/2（h2c）
+}
+
+// jsonTransport 是连接池配置的 JSON 格式
+type jsonTransport struct {
+	MaxIdleConns        int    `json:"max_idle_conns"`
+	MaxIdleConnsPerHost int    `json:"max_idle_conns_per_host"`
+	MaxConnsPerHost     int    `json:"max_conns_per_host"`
+	IdleConnTimeout     string `json:"idle_conn_timeout"`
+	KeepAlive           string `json:"keep_alive"`
+	DisableKeepAlives   bool   `json:"disable_keep_alives"`
+	H2C                 bool   `json:"h2c"`
+}
+
+// merge 用已填写的字段覆盖 base，无法解析的时间保持 base 中的值
+func (j jsonTransport) merge(base Transport) Transport {
+	if j.MaxIdleConns > 0 {
+		base.MaxIdleConns = j.MaxIdleConns
+	}
+	if j.MaxIdleConnsPerHost > 0 {
+		base.MaxIdleConnsPerHost = j.MaxIdleConnsPerHost
+	}
+	if j.MaxConnsPerHost > 0 {
+		base.MaxConnsPerHost = j.MaxConnsPerHost
+	}
+	if d, err := time.ParseDuration(j.IdleConnTimeout); err == nil && d > 0 {
+		base.IdleConnTimeout = d
+	}
+	if d, err := time.ParseDuration(j.KeepAlive); err == nil && d > 0 {
+		base.KeepAlive = d
+	}
+	if j.DisableKeepAlives {
+		base.DisableKeepAlives = true
+	}
+	if j.H2C {
+		base.H2C = true
+	}
+	return base
+}
+
+// TLS 证书配置，证书文件均为 PEM 格式
+type TLS struct {
+	CAFile     string `json:"ca_file"`              // 额外信任的根证书，与系统根证书一起使用
+	CertFile   string `json:"cert_file"`            // 双向 TLS 的客户端证书
+	KeyFile    string `json:"key_file"`             // 客户端证书的私钥
+	ServerName string `json:"server_name"`          // 校验证书时使用的服务器名称
+	Insecure   bool   `json:"insecure_skip_verify"` // 不校验服务器证书
+}
+
+// merge 用已填写的字段覆盖 base
+func (t TLS) merge(base TLS) TLS {
+	if t.CAFile != "" {
+		base.CAFile = t.CAFile
+	}
+	if t.CertFile != "" {
+		base.CertFile = t.CertFile
+		base.KeyFile = t.KeyFile
+	}
+	if t.ServerName != "" {
+		base.ServerName = t.ServerName
+	}
+	if t.Insecure {
+		base.Insecure = true
+	}
+	return base
+}
+
+// Proxy 出站代理配置
+type Proxy struct {
+	URL     string   `json:"url"`      // 代理地址，支持 http://、https://、socks5://
+	NoProxy []string `json:"no_proxy"` // 不走代理的主机，eg: localhost、.internal、10.0.0.0/8、*
+}
+
+// merge 用已填写的字段覆盖 base
+func (p Proxy) merge(base Proxy) Proxy {
+	if p.URL != "" {
+		base.URL = p.URL
+	}
+	if len(p.NoProxy) > 0 {
+		base.NoProxy = p.NoProxy
+	}
+	return base
+}
+
+// Target 单个被测服务的连接配置
+type Target struct {
+	Transport Transport
+	TLS       TLS
+	Proxy     Proxy
+}
+
+// jsonTarget 是被测服务配置的 JSON 格式，未填写的字段沿用全局配置
+type jsonTarget struct {
+	Transport jsonTransport `json:"transport"`
+	TLS       TLS           `json:"tls"`
+	Proxy     Proxy         `json:"proxy"`
+}
+
+// origin 将 base URL 规范化为 scheme://host:port 形式，无法解析时原样返回
+func origin(baseURL string) string {
+	u, err := url.Parse(baseURL)
+	if err != nil || u.Host == "" {
+		return baseURL
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+// RateLimit 限制发往被测服务的请求速率，0 表示不限制
+type RateLimit struct {
+	QPS                float64 `json:"qps"`                   // 全局每秒请求数
+	Burst              int     `json:"burst"`                 // 全局突发请求数
+	PerHostQPS         float64 `json:"per_host_qps"`          // 每个目标主机的每秒请求数
+	PerHostBurst       int     `json:"per_host_burst"`        // 每个目标主机的突发请求数
+	PerHostMaxInFlight int     `json:"per_host_max_inflight"` // 每个目标主机同时进行的最大请求数
 }
 
 func Load() (*Config, error) {
@@ -46,6 +292,28 @@ func Load() (*Config, error) {
 		timeout = 30 * time.Second // 默认值
 	}
 
+	// 解析慢请求阈值
+	slowThreshold, err := time.ParseDuration(jsonCfg.SlowThreshold)
+	if err != nil || slowThreshold <= 0 {
+		slowThreshold = 300 * time.Millisecond // 默认值
+	}
+
+	// 连接池默认值；每个主机的空闲连接数未配置时由 runner 按实际并发数设置，
+	// 压测的并发数可能由命令行参数指定
+	transport := jsonCfg.Transport.merge(Transport{
+		MaxIdleConns:    100,
+		IdleConnTimeout: 90 * time.Second,
+		KeepAlive:       30 * time.Second,
+	})
+	targets := make(map[string]Target, len(jsonCfg.Targets))
+	for baseURL, t := range jsonCfg.Targets {
+		targets[origin(baseURL)] = Target{
+			Transport: t.Transport.merge(transport),
+			TLS:       t.TLS.merge(jsonCfg.TLS),
+			Proxy:     t.Proxy.merge(jsonCfg.Proxy),
+		}
+	}
+
 	// 创建最终的配置对象
 	cfg := &Config{
 		ExcelPath:     jsonCfg.ExcelPath,
@@ -55,6 +323,19 @@ func Load() (*Config, error) {
 		Authorization: jsonCfg.Authorization,
 		Timeout:       timeout,
 		Concurrent:    jsonCfg.Concurrent,
+		Sheets:        jsonCfg.Sheets,
+		HooksSheet:    jsonCfg.HooksSheet,
+		Retry:         jsonCfg.Retry.policy(),
+		RateLimit:     jsonCfg.RateLimit,
+		FailFast:      jsonCfg.FailFast,
+		MaxFailures:   jsonCfg.MaxFailures,
+		Filter:        jsonCfg.Filter,
+		SlowThreshold: slowThreshold,
+		Transport:     transport,
+		TLS:           jsonCfg.TLS,
+		Proxy:         jsonCfg.Proxy,
+		Resolve:       jsonCfg.Resolve,
+		Targets:       targets,
 	}
 
 	// 设置默认值
@@ -67,6 +348,27 @@ func Load() (*Config, error) {
 	if cfg.SheetName == "" {
 		cfg.SheetName = "Sheet1"
 	}
+	if len(cfg.Sheets) == 0 {
+		cfg.Sheets = []string{cfg.SheetName}
+	}
+	if cfg.HooksSheet == "" {
+		cfg.HooksSheet = "Hooks"
+	}
+	if cfg.Retry.MaxAttempts == 0 {
+		cfg.Retry.MaxAttempts = 1
+	}
+	if cfg.Retry.Backoff == 0 {
+		cfg.Retry.Backoff = 200 * time.Millisecond
+	}
+	if cfg.RateLimit.Burst == 0 {
+		cfg.RateLimit.Burst = 1
+	}
+	if cfg.RateLimit.PerHostBurst == 0 {
+		cfg.RateLimit.PerHostBurst = 1
+	}
+	if len(cfg.Retry.On) == 0 {
+		cfg.Retry.On = []string{model.RetryOnNetwork, model.RetryOn5xx}
+	}
 
 	return cfg, nil
 }
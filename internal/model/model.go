@@ -1,5 +1,47 @@
 package model
 
+import "time"
+
+// 重试条件
+const (
+	RetryOnNetwork   = "network"   // 网络错误（连接失败、超时、读取响应失败）
+	RetryOn5xx       = "5xx"       // 响应状态码为 5xx
+	RetryOnAssertion = "assertion" // 响应与期望结果不匹配
+)
+
+// RetryPolicy 描述失败后的重试策略
+type RetryPolicy struct {
+	MaxAttempts int           // 最大尝试次数（含首次请求）
+	Backoff     time.Duration // 首次重试前的等待时间，之后每次翻倍
+	MaxBackoff  time.Duration // 等待时间上限，0 表示不限制
+	On          []string      // 触发重试的条件
+}
+
+// Delay 返回第 attempt 次请求失败后的等待时间
+func (p RetryPolicy) Delay(attempt int) time.Duration {
+	delay := p.Backoff
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if p.MaxBackoff > 0 && delay >= p.MaxBackoff {
+			break
+		}
+	}
+	if p.MaxBackoff > 0 && delay > p.MaxBackoff {
+		delay = p.MaxBackoff
+	}
+	return delay
+}
+
+// RetryOn 判断是否需要针对该条件重试
+func (p RetryPolicy) RetryOn(condition string) bool {
+	for _, c := range p.On {
+		if c == condition {
+			return true
+		}
+	}
+	return false
+}
+
 type TestCase struct {
 	CaseName    string            // 测试用例名称
 	Method      string            // HTTP方法
@@ -10,22 +52,193 @@ type TestCase struct {
 	Expected    string            // 期望结果
 	StrictMatch bool              // 是否完全匹配
 	BaseURL     string            // 基础URL（可选）
+	BaseURLFrom string            // base-url 的来源：本行、上方行或配置文件
 	Token       string            // 认证令牌（可选）
+	TokenFrom   string            // token 的来源：本行、首个用例或配置文件
 	Headers     map[string]string // 自定义请求头
+	DependsOn   []string          // 依赖的用例（用例名称或行号）
+	Scenario    string            // 所属场景
+	Group       string            // 所属分组，同组用例按行顺序串行执行
+	Sheet       string            // 所属工作表
+	Extract     map[string]string // 从响应中提取的变量（变量名 -> JSON 路径）
+	Cleanup     []string          // 运行结束后执行的清理请求
+	Retry       *RetryPolicy      // 用例级重试策略，未填写的字段沿用全局配置
+	Poll        *PollPolicy       // 轮询策略，为空时只请求一次
+	Disabled    bool              // 是否禁用
+	SkipReason  string            // 禁用原因
+	KnownIssue  string            // 已知缺陷的单号，失败时不影响整体结果
+	Quarantine  string            // 隔离原因（不稳定用例），失败时不影响整体结果
+	Timeout     time.Duration     // 用例级请求超时，0 表示使用全局配置
+	MaxLatency  time.Duration     // 响应耗时上限，超过时判定为失败，0 表示不限制
+	Redirect    *RedirectPolicy   // 重定向策略和断言，为空时跟随重定向
+	Tags        []string          // 标签
+	Priority    string            // 优先级
+}
+
+// PollPolicy 描述轮询等待的间隔和最长等待时间
+type PollPolicy struct {
+	Interval time.Duration
+	MaxWait  time.Duration
+}
+
+// RedirectPolicy 描述是否跟随重定向以及对重定向响应的断言
+type RedirectPolicy struct {
+	Follow   bool   // 是否跟随重定向
+	MaxHops  int    // 最多跟随的次数，0 表示使用默认值
+	Status   int    // 期望的第一次重定向状态码，0 表示不校验
+	Location string // 期望的第一次重定向 Location，支持正则，空表示不校验
+}
+
+// Asserts 判断是否需要校验重定向响应
+func (p RedirectPolicy) Asserts() bool {
+	return p.Status != 0 || p.Location != ""
+}
+
+// Redirect 记录一次重定向响应
+type Redirect struct {
+	StatusCode int
+	Location   string
+}
+
+// Status 用例执行状态
+type Status string
+
+const (
+	StatusPassed  Status = "passed"  // 通过
+	StatusFailed  Status = "failed"  // 响应与期望结果不匹配
+	StatusError   Status = "error"   // 请求无法创建、发送或读取，或用例配置错误
+	StatusSkipped Status = "skipped" // 跳过或未执行
+)
+
+// Label 返回状态的中文名称
+func (s Status) Label() string {
+	switch s {
+	case StatusPassed:
+		return "通过"
+	case StatusFailed:
+		return "失败"
+	case StatusError:
+		return "错误"
+	case StatusSkipped:
+		return "跳过"
+	}
+	return string(s)
 }
 
 type TestResult struct {
-	CaseNumber     int
-	CaseName       string
-	Method         string
-	Path           string
-	PathParams     map[string]string
-	QueryParams    map[string]string
-	RequestBody    string
-	Success        bool
-	ActualResult   string
-	ExpectedResult string
-	Error          string
-	Curl           string
-	ExecutionTime  float64 // 执行时间（毫秒）
+	CaseNumber      int
+	CaseName        string
+	Method          string
+	Path            string
+	PathParams      map[string]string
+	QueryParams     map[string]string
+	RequestBody     string
+	Status          Status
+	Scenario        string // 所属场景
+	Step            int    // 场景中的步骤序号
+	Sheet           string // 所属工作表
+	Hook            string // 钩子类型，普通用例为空
+	ActualResult    string
+	ExpectedResult  string
+	Error           string
+	Curl            string
+	ExecutionTime   float64 // 执行时间（毫秒），有重试时为最后一次请求的耗时
+	StatusCode      int
+	Attempts        []Attempt  // 每一次请求的记录
+	Polls           int        // 轮询次数
+	ConvergenceTime float64    // 轮询达到期望结果的耗时（毫秒）
+	KnownIssue      string     // 已知缺陷的单号
+	Quarantine      string     // 隔离原因
+	Redirects       []Redirect // 最后一次请求经过的重定向
+	Iteration       int        // 重复执行时为第几次执行，从 1 开始
+}
+
+// Passed 判断用例是否通过
+func (r TestResult) Passed() bool {
+	return r.Status == StatusPassed
+}
+
+// Failed 判断用例是否失败或出错
+func (r TestResult) Failed() bool {
+	return r.Status == StatusFailed || r.Status == StatusError
+}
+
+// Blocking 判断用例是否为需要关注的新失败，已知缺陷和隔离用例的失败不计入
+func (r TestResult) Blocking() bool {
+	return r.Failed() && r.KnownIssue == "" && r.Quarantine == ""
+}
+
+// UnexpectedPass 判断标记为已知缺陷的用例是否意外通过
+func (r TestResult) UnexpectedPass() bool {
+	return r.Passed() && r.KnownIssue != ""
+}
+
+// Skipped 判断用例是否被跳过
+func (r TestResult) Skipped() bool {
+	return r.Status == StatusSkipped
+}
+
+// Attempt 记录一次请求尝试
+type Attempt struct {
+	Number     int
+	StatusCode int
+	Latency    float64 // 请求耗时（毫秒）
+	Success    bool
+	Error      string
+}
+
+// LoadResult 记录一个用例的压测结果，耗时单位为毫秒
+type LoadResult struct {
+	CaseNumber int
+	CaseName   string
+	Sheet      string
+	Method     string
+	Path       string
+	Requests   int     // 请求数
+	Errors     int     // 出错或与期望结果不匹配的请求数
+	Throughput float64 // 每秒请求数
+	Mean       float64
+	P50        float64
+	P90        float64
+	P99        float64
+	Max        float64
+}
+
+// ErrorRate 返回错误率（百分比）
+func (r LoadResult) ErrorRate() float64 {
+	if r.Requests == 0 {
+		return 0
+	}
+	return float64(r.Errors) * 100 / float64(r.Requests)
+}
+
+// RequestPreview 记录 --dry-run 解析出的最终请求，不会实际发送
+type RequestPreview struct {
+	CaseNumber  int
+	CaseName    string
+	Sheet       string
+	Method      string
+	URL         string
+	Headers     []string // 最终请求头，eg: Authorization: xxx，按名称排序
+	Body        string
+	BaseURLFrom string
+	TokenFrom   string
+	Unresolved  []string // 需要运行时才能确定的变量（钩子或前序步骤提取）
+	Disabled    bool
+	Curl        string
+	Error       string // 无法构建请求的原因
+}
+
+// CleanupResult 记录一次清理请求的执行结果
+type CleanupResult struct {
+	CaseNumber    int // 登记清理请求的用例编号
+	CaseName      string
+	Sheet         string
+	Method        string
+	Path          string
+	StatusCode    int
+	Success       bool
+	Error         string
+	Curl          string
+	ExecutionTime float64 // 执行时间（毫秒）
 }
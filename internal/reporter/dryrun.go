@@ -0,0 +1,113 @@
+package reporter
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+
+	"regression_testing/internal/model"
+)
+
+const dryRunSheetNameFormat = "请求预览_%s"
+
+// 请求预览表头
+var dryRunHeaders = []string{
+	"用例编号", "用例名称", "工作表", "请求方法", "URL", "请求头", "请求体",
+	"base-url来源", "token来源", "运行时变量", "CURL命令", "备注",
+}
+
+// GenerateDryRunReport 输出 --dry-run 解析出的请求，并写入单独的请求预览工作表
+func (r *Reporter) GenerateDryRunReport(previews []model.RequestPreview) error {
+	r.printDryRunReport(previews)
+	return r.generateDryRunExcelReport(previews)
+}
+
+func (r *Reporter) printDryRunReport(previews []model.RequestPreview) {
+	for _, p := range previews {
+		fmt.Printf("\n用例 %d %s [%s]\n", p.CaseNumber, p.CaseName, p.Sheet)
+		if note := previewNote(p); note != "" {
+			fmt.Printf("\033[33m%s\033[0m\n", note)
+		}
+		if p.Error != "" {
+			continue
+		}
+		fmt.Printf("%s %s\n", p.Method, p.URL)
+		fmt.Printf("base-url 来源: %s, token 来源: %s\n", p.BaseURLFrom, p.TokenFrom)
+		for _, header := range p.Headers {
+			fmt.Printf("  %s\n", header)
+		}
+		if p.Body != "" {
+			fmt.Printf("  %s\n", p.Body)
+		}
+		fmt.Println(p.Curl)
+	}
+	fmt.Printf("\n请求预览: %d 个用例，未发送任何请求\n", len(previews))
+}
+
+func (r *Reporter) generateDryRunExcelReport(previews []model.RequestPreview) error {
+	f, err := excelize.OpenFile(r.config.ExcelPath)
+	if err != nil {
+		return fmt.Errorf("打开Excel文件失败: %v", err)
+	}
+	defer f.Close()
+
+	sheetName := fmt.Sprintf(dryRunSheetNameFormat, time.Now().Format(timeFormat))
+	index, err := f.NewSheet(sheetName)
+	if err != nil {
+		return fmt.Errorf("创建工作表失败: %v", err)
+	}
+	f.SetActiveSheet(index)
+
+	errorStyle := newFillStyle(f, errorBgColor)
+	warningStyle := newFillStyle(f, warningBgColor)
+	skippedStyle := newFillStyle(f, skippedBgColor)
+	for i, header := range dryRunHeaders {
+		f.SetCellValue(sheetName, fmt.Sprintf("%c1", minColumn+i), header)
+	}
+	for i, p := range previews {
+		row := i + 2
+		cells := []interface{}{
+			p.CaseNumber, p.CaseName, p.Sheet, p.Method, p.URL, strings.Join(p.Headers, "\n"), p.Body,
+			p.BaseURLFrom, p.TokenFrom, strings.Join(p.Unresolved, ", "), p.Curl, previewNote(p),
+		}
+		style := 0
+		switch {
+		case p.Error != "":
+			style = errorStyle
+		case p.Disabled:
+			style = skippedStyle
+		case len(p.Unresolved) > 0:
+			style = warningStyle
+		}
+		for j, cell := range cells {
+			cellName := fmt.Sprintf("%c%d", minColumn+j, row)
+			f.SetCellValue(sheetName, cellName, cell)
+			if style != 0 {
+				f.SetCellStyle(sheetName, cellName, cellName, style)
+			}
+		}
+	}
+
+	if err := f.Save(); err != nil {
+		return fmt.Errorf("保存报告失败: %v", err)
+	}
+	fmt.Printf("请求预览已保存到工作表: %s\n", sheetName)
+	return nil
+}
+
+// previewNote 返回请求预览的备注：构建失败的原因、禁用状态和运行时才能确定的变量
+func previewNote(p model.RequestPreview) string {
+	var notes []string
+	if p.Error != "" {
+		notes = append(notes, p.Error)
+	}
+	if p.Disabled {
+		notes = append(notes, "已禁用，实际执行时会跳过")
+	}
+	if len(p.Unresolved) > 0 {
+		notes = append(notes, "运行时替换的变量: "+strings.Join(p.Unresolved, ", "))
+	}
+	return strings.Join(notes, "; ")
+}
@@ -0,0 +1,79 @@
+package reporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"regression_testing/internal/model"
+)
+
+// RunResults 是一次运行（或一个分片）的结果，用于写入 JSON 文件和合并分片结果
+type RunResults struct {
+	Shard    string                `json:"shard,omitempty"` // 分片，eg: 1/3
+	Duration time.Duration         `json:"duration"`        // 总执行时间（纳秒）
+	Results  []model.TestResult    `json:"results"`
+	Cleanups []model.CleanupResult `json:"cleanups"`
+}
+
+// WriteJSON 将运行结果写入 JSON 文件
+func WriteJSON(path string, run RunResults) error {
+	data, err := json.MarshalIndent(run, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// ReadJSON 读取 WriteJSON 写入的运行结果
+func ReadJSON(path string) (RunResults, error) {
+	var run RunResults
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return run, err
+	}
+	if err := json.Unmarshal(data, &run); err != nil {
+		return run, fmt.Errorf("无法解析 %s: %v", path, err)
+	}
+	return run, nil
+}
+
+// Merge 合并各分片的结果：用例按工作表顺序和行号排列，钩子结果排在最后；
+// 分片并行执行，总执行时间取最长的分片
+func Merge(runs []RunResults, sheets []string) RunResults {
+	rank := make(map[string]int, len(sheets))
+	for i, name := range sheets {
+		rank[name] = i
+	}
+	sheetRank := func(name string) int {
+		if i, ok := rank[name]; ok {
+			return i
+		}
+		return len(sheets)
+	}
+
+	var merged RunResults
+	for _, run := range runs {
+		merged.Results = append(merged.Results, run.Results...)
+		merged.Cleanups = append(merged.Cleanups, run.Cleanups...)
+		if run.Duration > merged.Duration {
+			merged.Duration = run.Duration
+		}
+	}
+	sort.SliceStable(merged.Results, func(i, j int) bool {
+		a, b := merged.Results[i], merged.Results[j]
+		if (a.Hook == "") != (b.Hook == "") {
+			return a.Hook == ""
+		}
+		if a.Hook != "" {
+			return false // 钩子结果保持各分片中的顺序
+		}
+		if ra, rb := sheetRank(a.Sheet), sheetRank(b.Sheet); ra != rb {
+			return ra < rb
+		}
+		return a.CaseNumber < b.CaseNumber
+	})
+	return merged
+}
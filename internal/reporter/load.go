@@ -0,0 +1,106 @@
+package reporter
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+
+	"regression_testing/internal/model"
+)
+
+const loadSheetNameFormat = "压测报告_%s"
+
+// 压测报告表头
+var loadHeaders = []string{
+	"用例编号", "用例名称", "工作表", "请求方法", "请求路径", "请求数", "错误数", "错误率(%)",
+	"吞吐量(请求/秒)", "平均耗时(ms)", "P50(ms)", "P90(ms)", "P99(ms)", "最大耗时(ms)",
+}
+
+// GenerateLoadReport 输出压测结果和钩子登记的清理结果，并写入单独的压测报告工作表
+func (r *Reporter) GenerateLoadReport(results []model.LoadResult, cleanups []model.CleanupResult, duration time.Duration) error {
+	r.printLoadReport(results, duration)
+	r.printCleanupReport(cleanups)
+	return r.generateLoadExcelReport(results, duration)
+}
+
+func (r *Reporter) printLoadReport(results []model.LoadResult, duration time.Duration) {
+	requests, errors, throughput := 0, 0, 0.0
+	fmt.Printf("\n压测汇总\n")
+	for _, res := range results {
+		requests += res.Requests
+		errors += res.Errors
+		throughput += res.Throughput
+		line := fmt.Sprintf("用例 %d %s: 请求 %d, 错误率 %.2f%%, 吞吐量 %.1f/s, P50 %.3fms, P90 %.3fms, P99 %.3fms",
+			res.CaseNumber, res.CaseName, res.Requests, res.ErrorRate(), res.Throughput, res.P50, res.P90, res.P99)
+		if res.Errors > 0 {
+			fmt.Printf("\033[31m%s\033[0m\n", line)
+		} else {
+			fmt.Println(line)
+		}
+	}
+	fmt.Printf("总执行时间: %.6fms\n", float64(duration.Microseconds())/1000)
+	fmt.Printf("总请求数: %d\n", requests)
+	fmt.Printf("总吞吐量: %.1f/s\n", throughput)
+	if errors > 0 {
+		fmt.Printf("\033[31m错误请求数: %d\033[0m\n", errors)
+	} else {
+		fmt.Printf("错误请求数: %d\n", errors)
+	}
+}
+
+func (r *Reporter) generateLoadExcelReport(results []model.LoadResult, duration time.Duration) error {
+	f, err := excelize.OpenFile(r.config.ExcelPath)
+	if err != nil {
+		return fmt.Errorf("打开Excel文件失败: %v", err)
+	}
+	defer f.Close()
+
+	sheetName := fmt.Sprintf(loadSheetNameFormat, time.Now().Format(timeFormat))
+	index, err := f.NewSheet(sheetName)
+	if err != nil {
+		return fmt.Errorf("创建工作表失败: %v", err)
+	}
+	f.SetActiveSheet(index)
+
+	errorStyle := newFillStyle(f, errorBgColor)
+	for i, header := range loadHeaders {
+		f.SetCellValue(sheetName, fmt.Sprintf("%c1", minColumn+i), header)
+	}
+	for i, res := range results {
+		row := i + 2
+		cells := []interface{}{
+			res.CaseNumber, res.CaseName, res.Sheet, res.Method, res.Path, res.Requests, res.Errors, res.ErrorRate(),
+			res.Throughput, res.Mean, res.P50, res.P90, res.P99, res.Max,
+		}
+		for j, cell := range cells {
+			cellName := fmt.Sprintf("%c%d", minColumn+j, row)
+			f.SetCellValue(sheetName, cellName, cell)
+			if res.Errors > 0 {
+				f.SetCellStyle(sheetName, cellName, cellName, errorStyle)
+			}
+		}
+	}
+
+	summaryRow := len(results) + 3
+	f.SetCellValue(sheetName, fmt.Sprintf("A%d", summaryRow), "压测汇总")
+	f.SetCellValue(sheetName, fmt.Sprintf("A%d", summaryRow+1), fmt.Sprintf("总执行时间: %.6fms", float64(duration.Microseconds())/1000))
+	f.SetCellValue(sheetName, fmt.Sprintf("A%d", summaryRow+2), fmt.Sprintf("并发: %d", r.loadConcurrency()))
+	if r.config.LoadTest.RPS > 0 {
+		f.SetCellValue(sheetName, fmt.Sprintf("A%d", summaryRow+3), fmt.Sprintf("目标速率: %.0f 请求/秒", r.config.LoadTest.RPS))
+	}
+
+	if err := f.Save(); err != nil {
+		return fmt.Errorf("保存报告失败: %v", err)
+	}
+	fmt.Printf("压测报告已保存到工作表: %s\n", sheetName)
+	return nil
+}
+
+// loadConcurrency 返回压测实际使用的并发数
+func (r *Reporter) loadConcurrency() int {
+	if r.config.LoadTest.Concurrency > 0 {
+		return r.config.LoadTest.Concurrency
+	}
+	return r.config.Concurrent
+}
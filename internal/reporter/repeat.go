@@ -0,0 +1,165 @@
+package reporter
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/xuri/excelize/v2"
+
+	"regression_testing/internal/model"
+)
+
+// repeatSummary 汇总一个用例在重复执行中的表现
+type repeatSummary struct {
+	Sheet      string
+	CaseNumber int
+	Name       string
+	Runs       int       // 实际执行的次数，不含跳过
+	Passed     int       // 通过的次数
+	Latencies  []float64 // 每次执行的耗时（毫秒）
+	Stable     bool      // 每次的状态码和响应体是否一致
+	first      model.TestResult
+}
+
+// PassRate 返回通过率（百分比）
+func (s repeatSummary) PassRate() float64 {
+	if s.Runs == 0 {
+		return 0
+	}
+	return float64(s.Passed) * 100 / float64(s.Runs)
+}
+
+// Flaky 判断用例是否不稳定：时而通过时而失败，或者响应不一致
+func (s repeatSummary) Flaky() bool {
+	return (s.Passed > 0 && s.Passed < s.Runs) || !s.Stable
+}
+
+// LatencyStats 返回耗时的平均值和标准差
+func (s repeatSummary) LatencyStats() (mean, stddev float64) {
+	if len(s.Latencies) == 0 {
+		return 0, 0
+	}
+	for _, l := range s.Latencies {
+		mean += l
+	}
+	mean /= float64(len(s.Latencies))
+	for _, l := range s.Latencies {
+		stddev += (l - mean) * (l - mean)
+	}
+	return mean, math.Sqrt(stddev / float64(len(s.Latencies)))
+}
+
+// summarizeRepeats 按用例汇总重复执行的结果，没有重复执行时返回空
+func summarizeRepeats(results []model.TestResult) []repeatSummary {
+	type key struct {
+		sheet string
+		num   int
+	}
+	var summaries []repeatSummary
+	index := make(map[key]int)
+	for _, result := range results {
+		if result.Iteration == 0 || result.Hook != "" {
+			continue
+		}
+		k := key{result.Sheet, result.CaseNumber}
+		i, ok := index[k]
+		if !ok {
+			i = len(summaries)
+			index[k] = i
+			summaries = append(summaries, repeatSummary{
+				Sheet:      result.Sheet,
+				CaseNumber: result.CaseNumber,
+				Name:       result.CaseName,
+				Stable:     true,
+			})
+		}
+		if result.Skipped() {
+			continue
+		}
+
+		s := &summaries[i]
+		if s.Runs == 0 {
+			s.first = result
+		} else if result.StatusCode != s.first.StatusCode || result.ActualResult != s.first.ActualResult {
+			s.Stable = false
+		}
+		s.Runs++
+		if result.Passed() {
+			s.Passed++
+		}
+		s.Latencies = append(s.Latencies, result.ExecutionTime)
+	}
+
+	// 打乱执行顺序时结果是乱序的，按工作表和行号排列
+	sheetRank := make(map[string]int)
+	for _, s := range summaries {
+		if _, ok := sheetRank[s.Sheet]; !ok {
+			sheetRank[s.Sheet] = len(sheetRank)
+		}
+	}
+	sort.SliceStable(summaries, func(i, j int) bool {
+		a, b := summaries[i], summaries[j]
+		if a.Sheet != b.Sheet {
+			return sheetRank[a.Sheet] < sheetRank[b.Sheet]
+		}
+		return a.CaseNumber < b.CaseNumber
+	})
+	return summaries
+}
+
+// printRepeatSummary 输出重复执行汇总，不稳定的用例标黄
+func (r *Reporter) printRepeatSummary(summaries []repeatSummary) {
+	if len(summaries) == 0 {
+		return
+	}
+
+	flaky := 0
+	fmt.Printf("\n重复执行汇总\n")
+	for _, s := range summaries {
+		mean, stddev := s.LatencyStats()
+		line := fmt.Sprintf("用例 %d %s: 执行 %d 次, 通过率 %.0f%%, 耗时 %.3fms ± %.3fms, 响应%s",
+			s.CaseNumber, s.Name, s.Runs, s.PassRate(), mean, stddev, stableLabel(s.Stable))
+		if s.Flaky() {
+			flaky++
+			fmt.Printf("\033[33m%s\033[0m\n", line)
+		} else {
+			fmt.Println(line)
+		}
+	}
+	fmt.Printf("不稳定用例数: %d\n", flaky)
+}
+
+// writeRepeatSummary 写入重复执行汇总，返回下一个可用的起始行
+func (r *Reporter) writeRepeatSummary(f *excelize.File, sheet string, startRow int, summaries []repeatSummary) int {
+	if len(summaries) == 0 {
+		return startRow
+	}
+
+	warningStyle := newFillStyle(f, warningBgColor)
+	f.SetCellValue(sheet, fmt.Sprintf("A%d", startRow), "重复执行汇总")
+	headers := []string{"工作表", "用例编号", "用例名称", "执行次数", "通过次数", "通过率(%)", "平均耗时(ms)", "耗时标准差(ms)", "响应一致"}
+	for i, header := range headers {
+		f.SetCellValue(sheet, fmt.Sprintf("%c%d", minColumn+i, startRow+1), header)
+	}
+	for i, s := range summaries {
+		row := startRow + 2 + i
+		mean, stddev := s.LatencyStats()
+		cells := []interface{}{s.Sheet, s.CaseNumber, s.Name, s.Runs, s.Passed, math.Round(s.PassRate()), mean, stddev, s.Stable}
+		for j, cell := range cells {
+			cellName := fmt.Sprintf("%c%d", minColumn+j, row)
+			f.SetCellValue(sheet, cellName, cell)
+			if s.Flaky() {
+				f.SetCellStyle(sheet, cellName, cellName, warningStyle)
+			}
+		}
+	}
+	return startRow + len(summaries) + 3
+}
+
+func stableLabel(stable bool) string {
+	if stable {
+		return "一致"
+	}
+	return "不一致"
+}
@@ -16,24 +16,26 @@ const (
 	defaultSheetNameFormat = "测试报告_%s"
 	timeFormat             = "2006-01-02_15-04-05"
 	minColumn              = 'A'
-	maxColumn              = 'L'
+	maxColumn              = 'U'
 	defaultColumnWidth     = 12
 
 	// 样式相关
-	patternType    = "pattern"
-	patternValue   = 1
-	errorBgColor   = "FF5900"
-	warningBgColor = "FFEB9C"
-
-	// 时间阈值
-	slowTestThreshold = 300 // 300毫秒
+	patternType       = "pattern"
+	patternValue      = 1
+	errorBgColor      = "FF5900"
+	warningBgColor    = "FFEB9C"
+	skippedBgColor    = "D9D9D9"
+	knownIssueBgColor = "E4DFEC"
+	xpassBgColor      = "BDD7EE"
 )
 
 // 表头定义
 var excelHeaders = []string{
 	"用例编号", "用例名称", "请求方法", "请求路径", "路径参数",
 	"查询参数", "请求体", "期望结果", "实际结果", "测试结果",
-	"错误信息", "CURL命令",
+	"错误信息", "CURL命令", "场景", "步骤",
+	"工作表", "钩子", "重试记录", "轮询",
+	"已知问题", "重定向", "执行轮次",
 }
 
 type Reporter struct {
@@ -44,12 +46,13 @@ func New(cfg *config.Config) *Reporter {
 	return &Reporter{config: cfg}
 }
 
-func (r *Reporter) GenerateReport(results []model.TestResult, duration time.Duration) error {
+func (r *Reporter) GenerateReport(results []model.TestResult, cleanups []model.CleanupResult, duration time.Duration) error {
 	r.printConsoleReport(results, duration)
-	return r.generateExcelReport(results, duration)
+	r.printCleanupReport(cleanups)
+	return r.generateExcelReport(results, cleanups, duration)
 }
 
-func (r *Reporter) generateExcelReport(results []model.TestResult, duration time.Duration) error {
+func (r *Reporter) generateExcelReport(results []model.TestResult, cleanups []model.CleanupResult, duration time.Duration) error {
 	// 打开原有的 Excel 文件
 	f, err := excelize.OpenFile(r.config.ExcelPath)
 	if err != nil {
@@ -85,7 +88,16 @@ func (r *Reporter) generateExcelReport(results []model.TestResult, duration time
 
 	// 写入汇总信息
 	summaryRow := len(results) + 3
-	r.writeSummary(f, sheetName, summaryRow, results, duration)
+	nextRow := r.writeSummary(f, sheetName, summaryRow, results, duration)
+
+	// 写入场景汇总
+	nextRow = r.writeScenarioSummary(f, sheetName, nextRow, summarizeScenarios(results))
+
+	// 写入重复执行汇总
+	nextRow = r.writeRepeatSummary(f, sheetName, nextRow, summarizeRepeats(results))
+
+	// 写入清理结果
+	r.writeCleanups(f, sheetName, nextRow, cleanups)
 
 	// 保存文件
 	if err := f.Save(); err != nil {
@@ -97,23 +109,11 @@ func (r *Reporter) generateExcelReport(results []model.TestResult, duration time
 }
 
 func (r *Reporter) writeTestResult(f *excelize.File, sheet string, row int, result model.TestResult) {
-	// 设置错误样式（红色背景）
-	errorStyle, _ := f.NewStyle(&excelize.Style{
-		Fill: excelize.Fill{
-			Type:    patternType,
-			Pattern: patternValue,
-			Color:   []string{errorBgColor},
-		},
-	})
-
-	// 设置警告样式（黄色背景）
-	warningStyle, _ := f.NewStyle(&excelize.Style{
-		Fill: excelize.Fill{
-			Type:    patternType,
-			Pattern: patternValue,
-			Color:   []string{warningBgColor},
-		},
-	})
+	errorStyle := newFillStyle(f, errorBgColor)           // 失败：红色背景
+	warningStyle := newFillStyle(f, warningBgColor)       // 慢请求：黄色背景
+	skippedStyle := newFillStyle(f, skippedBgColor)       // 跳过：灰色背景
+	knownIssueStyle := newFillStyle(f, knownIssueBgColor) // 已知缺陷或隔离用例失败：紫色背景
+	xpassStyle := newFillStyle(f, xpassBgColor)           // 已知缺陷意外通过：蓝色背景
 
 	// 写入测试结果
 	cells := []interface{}{
@@ -126,60 +126,330 @@ func (r *Reporter) writeTestResult(f *excelize.File, sheet string, row int, resu
 		result.RequestBody,
 		result.ExpectedResult,
 		result.ActualResult,
-		result.Success,
+		result.Status.Label(),
 		result.Error,
 		result.Curl,
+		result.Scenario,
+		formatStep(result.Step),
+		result.Sheet,
+		result.Hook,
+		formatAttempts(result.Attempts),
+		formatPolls(result),
+		formatMark(result),
+		formatRedirects(result),
+		formatIteration(result),
 	}
 
 	for i, cell := range cells {
 		cellName := fmt.Sprintf("%c%d", minColumn+i, row)
 		f.SetCellValue(sheet, cellName, cell)
 
-		// 如果测试失败，设置红色背景
-		if !result.Success {
+		// 如果测试被跳过，设置灰色背景；如果测试失败或出错，设置红色背景
+		if result.Skipped() {
+			f.SetCellStyle(sheet, cellName, cellName, skippedStyle)
+		} else if result.Failed() && !result.Blocking() {
+			f.SetCellStyle(sheet, cellName, cellName, knownIssueStyle)
+		} else if result.Failed() {
 			f.SetCellStyle(sheet, cellName, cellName, errorStyle)
-		} else if result.ExecutionTime > slowTestThreshold { // 如果执行时间超过阈值，设置黄色背景
+		} else if result.UnexpectedPass() {
+			f.SetCellStyle(sheet, cellName, cellName, xpassStyle)
+		} else if result.ExecutionTime > float64(r.config.SlowThreshold.Milliseconds()) { // 如果执行时间超过阈值，设置黄色背景
 			f.SetCellStyle(sheet, cellName, cellName, warningStyle)
 		}
 	}
 }
 
-func (r *Reporter) writeSummary(f *excelize.File, sheet string, startRow int, results []model.TestResult, duration time.Duration) {
+// writeSummary 写入汇总信息，返回下一个可用的起始行
+func (r *Reporter) writeSummary(f *excelize.File, sheet string, startRow int, results []model.TestResult, duration time.Duration) int {
 	// 计算统计信息
-	totalTests := len(results)
-	failedTests := 0
-	for _, result := range results {
-		if !result.Success {
-			failedTests++
-		}
-	}
+	counts := countResults(results)
 
 	// 写入汇总信息
 	f.SetCellValue(sheet, fmt.Sprintf("A%d", startRow), "测试汇总")
 	f.SetCellValue(sheet, fmt.Sprintf("A%d", startRow+1), fmt.Sprintf("总执行时间: %.6fms", float64(duration.Microseconds())/1000))
-	f.SetCellValue(sheet, fmt.Sprintf("A%d", startRow+2), fmt.Sprintf("总用例数: %d", totalTests))
-	f.SetCellValue(sheet, fmt.Sprintf("A%d", startRow+3), fmt.Sprintf("失败用例数: %d", failedTests))
+	f.SetCellValue(sheet, fmt.Sprintf("A%d", startRow+2), fmt.Sprintf("总用例数: %d", counts.total))
+	f.SetCellValue(sheet, fmt.Sprintf("A%d", startRow+3), fmt.Sprintf("失败用例数: %d", counts.failed))
+	f.SetCellValue(sheet, fmt.Sprintf("A%d", startRow+4), fmt.Sprintf("错误用例数: %d", counts.errors))
+	f.SetCellValue(sheet, fmt.Sprintf("A%d", startRow+5), fmt.Sprintf("跳过用例数: %d", counts.skipped))
+	f.SetCellValue(sheet, fmt.Sprintf("A%d", startRow+6), fmt.Sprintf("已知问题失败数: %d", counts.knownIssues))
+	f.SetCellValue(sheet, fmt.Sprintf("A%d", startRow+7), fmt.Sprintf("隔离用例失败数: %d", counts.quarantined))
+	f.SetCellValue(sheet, fmt.Sprintf("A%d", startRow+8), fmt.Sprintf("已知问题意外通过数: %d", counts.unexpectedPasses))
+	return startRow + 10
 }
 
-func (r *Reporter) printConsoleReport(results []model.TestResult, duration time.Duration) {
-	// 计算统计信息
-	totalTests := len(results)
-	failedTests := 0
-	for _, result := range results {
-		if !result.Success {
-			failedTests++
+// writeScenarioSummary 写入场景汇总，返回下一个可用的起始行
+func (r *Reporter) writeScenarioSummary(f *excelize.File, sheet string, startRow int, scenarios []scenarioSummary) int {
+	if len(scenarios) == 0 {
+		return startRow
+	}
+
+	f.SetCellValue(sheet, fmt.Sprintf("A%d", startRow), "场景汇总")
+	for i, header := range []string{"场景", "步骤数", "通过", "失败", "跳过", "场景结果"} {
+		f.SetCellValue(sheet, fmt.Sprintf("%c%d", minColumn+i, startRow+1), header)
+	}
+	for i, s := range scenarios {
+		row := startRow + 2 + i
+		cells := []interface{}{s.Name, s.Steps, s.Passed, s.Failed, s.Skipped, s.Success()}
+		for j, cell := range cells {
+			f.SetCellValue(sheet, fmt.Sprintf("%c%d", minColumn+j, row), cell)
 		}
 	}
+	return startRow + len(scenarios) + 3
+}
+
+func (r *Reporter) writeCleanups(f *excelize.File, sheet string, startRow int, cleanups []model.CleanupResult) {
+	if len(cleanups) == 0 {
+		return
+	}
+
+	errorStyle := newFillStyle(f, errorBgColor)
+
+	f.SetCellValue(sheet, fmt.Sprintf("A%d", startRow), "清理结果")
+	for i, header := range []string{"用例编号", "用例名称", "请求方法", "请求路径", "状态码", "清理结果", "错误信息", "CURL命令", "工作表"} {
+		f.SetCellValue(sheet, fmt.Sprintf("%c%d", minColumn+i, startRow+1), header)
+	}
+	for i, c := range cleanups {
+		row := startRow + 2 + i
+		cells := []interface{}{c.CaseNumber, c.CaseName, c.Method, c.Path, c.StatusCode, c.Success, c.Error, c.Curl, c.Sheet}
+		for j, cell := range cells {
+			cellName := fmt.Sprintf("%c%d", minColumn+j, row)
+			f.SetCellValue(sheet, cellName, cell)
+			if !c.Success {
+				f.SetCellStyle(sheet, cellName, cellName, errorStyle)
+			}
+		}
+	}
+}
+
+func (r *Reporter) printConsoleReport(results []model.TestResult, duration time.Duration) {
+	// 计算统计信息
+	counts := countResults(results)
 
 	// 输出汇总信息
 	fmt.Printf("\n测试汇总\n")
 	fmt.Printf("总执行时间: %.6fms\n", float64(duration.Microseconds())/1000)
-	fmt.Printf("总用例数: %d\n", totalTests)
-	if failedTests > 0 {
-		fmt.Printf("\033[31m失败用例数: %d\033[0m\n", failedTests)
+	fmt.Printf("总用例数: %d\n", counts.total)
+	if counts.failed > 0 {
+		fmt.Printf("\033[31m失败用例数: %d\033[0m\n", counts.failed)
+	} else {
+		fmt.Printf("失败用例数: %d\n", counts.failed)
+	}
+	if counts.errors > 0 {
+		fmt.Printf("\033[31m错误用例数: %d\033[0m\n", counts.errors)
 	} else {
-		fmt.Printf("失败用例数: %d\n", failedTests)
+		fmt.Printf("错误用例数: %d\n", counts.errors)
+	}
+	fmt.Printf("跳过用例数: %d\n", counts.skipped)
+	if counts.knownIssues > 0 {
+		fmt.Printf("已知问题失败数: %d\n", counts.knownIssues)
+	}
+	if counts.quarantined > 0 {
+		fmt.Printf("隔离用例失败数: %d\n", counts.quarantined)
+	}
+
+	// 已知缺陷的用例意外通过，说明缺陷可能已修复
+	for _, result := range results {
+		if result.UnexpectedPass() {
+			fmt.Printf("\033[33m意外通过: 用例 %d %s（已知问题 %s 可能已修复）\033[0m\n", result.CaseNumber, result.CaseName, result.KnownIssue)
+		}
+	}
+
+	// 输出场景汇总
+	scenarios := summarizeScenarios(results)
+	if len(scenarios) > 0 {
+		fmt.Printf("\n场景汇总\n")
+	}
+	for _, s := range scenarios {
+		line := fmt.Sprintf("%s: 步骤 %d, 通过 %d, 失败 %d, 跳过 %d", s.Name, s.Steps, s.Passed, s.Failed, s.Skipped)
+		if s.Success() {
+			fmt.Println(line)
+		} else {
+			fmt.Printf("\033[31m%s\033[0m\n", line)
+		}
+	}
+
+	// 输出重复执行汇总
+	r.printRepeatSummary(summarizeRepeats(results))
+}
+
+func (r *Reporter) printCleanupReport(cleanups []model.CleanupResult) {
+	if len(cleanups) == 0 {
+		return
+	}
+
+	failed := 0
+	for _, c := range cleanups {
+		if !c.Success {
+			failed++
+		}
 	}
+	fmt.Printf("\n清理汇总\n")
+	fmt.Printf("清理请求数: %d\n", len(cleanups))
+	if failed == 0 {
+		fmt.Printf("清理失败数: %d\n", failed)
+		return
+	}
+	fmt.Printf("\033[31m清理失败数: %d\033[0m\n", failed)
+	for _, c := range cleanups {
+		if !c.Success {
+			fmt.Printf("\033[31m%s %s (用例 %d %s): %s\033[0m\n", c.Method, c.Path, c.CaseNumber, c.CaseName, c.Error)
+		}
+	}
+}
+
+// resultCounts 按状态统计的用例数
+type resultCounts struct {
+	total            int
+	failed           int
+	errors           int
+	skipped          int
+	knownIssues      int // 标记为已知缺陷的失败用例
+	quarantined      int // 隔离的失败用例
+	unexpectedPasses int // 标记为已知缺陷但通过的用例
+}
+
+// countResults 按状态统计用例数
+func countResults(results []model.TestResult) resultCounts {
+	counts := resultCounts{total: len(results)}
+	for _, result := range results {
+		switch result.Status {
+		case model.StatusFailed:
+			counts.failed++
+		case model.StatusError:
+			counts.errors++
+		case model.StatusSkipped:
+			counts.skipped++
+		}
+		switch {
+		case result.Failed() && result.KnownIssue != "":
+			counts.knownIssues++
+		case result.Failed() && result.Quarantine != "":
+			counts.quarantined++
+		case result.UnexpectedPass():
+			counts.unexpectedPasses++
+		}
+	}
+	return counts
+}
+
+// scenarioSummary 汇总一个场景中各步骤的执行结果
+type scenarioSummary struct {
+	Name    string
+	Steps   int
+	Passed  int
+	Failed  int
+	Skipped int
+}
+
+func (s scenarioSummary) Success() bool {
+	return s.Passed == s.Steps
+}
+
+// summarizeScenarios 按场景出现的顺序汇总结果
+func summarizeScenarios(results []model.TestResult) []scenarioSummary {
+	var scenarios []scenarioSummary
+	index := make(map[string]int)
+	for _, result := range results {
+		if result.Scenario == "" {
+			continue
+		}
+		i, ok := index[result.Scenario]
+		if !ok {
+			i = len(scenarios)
+			index[result.Scenario] = i
+			scenarios = append(scenarios, scenarioSummary{Name: result.Scenario})
+		}
+		scenarios[i].Steps++
+		switch {
+		case result.Skipped():
+			scenarios[i].Skipped++
+		case result.Passed():
+			scenarios[i].Passed++
+		default:
+			scenarios[i].Failed++
+		}
+	}
+	return scenarios
+}
+
+func formatStep(step int) string {
+	if step == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d", step)
+}
+
+// formatAttempts 输出每次请求的状态码、耗时和错误，只请求一次时不输出
+func formatAttempts(attempts []model.Attempt) string {
+	if len(attempts) <= 1 {
+		return ""
+	}
+	lines := make([]string, 0, len(attempts))
+	for _, a := range attempts {
+		line := fmt.Sprintf("#%d 状态码:%d 耗时:%.3fms", a.Number, a.StatusCode, a.Latency)
+		if a.Success {
+			line += " 通过"
+		} else if a.Error != "" {
+			line += " " + a.Error
+		} else {
+			line += " 未通过"
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// formatPolls 输出轮询次数和达到期望结果的耗时
+func formatPolls(result model.TestResult) string {
+	if result.Polls == 0 {
+		return ""
+	}
+	if result.Passed() {
+		return fmt.Sprintf("轮询 %d 次, 收敛耗时 %.3fms", result.Polls, result.ConvergenceTime)
+	}
+	return fmt.Sprintf("轮询 %d 次, 未收敛", result.Polls)
+}
+
+// formatRedirects 输出重定向链，eg: 302 -> /login; 301 -> https://sso/login
+func formatRedirects(result model.TestResult) string {
+	hops := make([]string, 0, len(result.Redirects))
+	for _, hop := range result.Redirects {
+		hops = append(hops, fmt.Sprintf("%d -> %s", hop.StatusCode, hop.Location))
+	}
+	return strings.Join(hops, "; ")
+}
+
+// formatIteration 输出重复执行时的轮次
+func formatIteration(result model.TestResult) string {
+	if result.Iteration == 0 {
+		return ""
+	}
+	return fmt.Sprintf("第 %d 次", result.Iteration)
+}
+
+// formatMark 输出已知缺陷单号或隔离原因
+func formatMark(result model.TestResult) string {
+	switch {
+	case result.UnexpectedPass():
+		return fmt.Sprintf("%s（意外通过）", result.KnownIssue)
+	case result.KnownIssue != "":
+		return result.KnownIssue
+	case result.Quarantine != "":
+		return "隔离: " + result.Quarantine
+	}
+	return ""
+}
+
+// newFillStyle 创建纯色背景样式
+func newFillStyle(f *excelize.File, color string) int {
+	style, _ := f.NewStyle(&excelize.Style{
+		Fill: excelize.Fill{
+			Type:    patternType,
+			Pattern: patternValue,
+			Color:   []string{color},
+		},
+	})
+	return style
 }
 
 func formatParams(params map[string]string) string {
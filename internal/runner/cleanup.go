@@ -0,0 +1,136 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"regression_testing/internal/model"
+)
+
+// cleanup 是用例登记的清理请求，沿用所属用例的 base-url、token、请求头和 Cookie
+type cleanup struct {
+	caseNum  int
+	testCase model.TestCase
+	jar      http.CookieJar
+}
+
+// registerCleanups 登记用例的清理请求，清理列中引用的变量在登记时替换
+func (r *Runner) registerCleanups(caseNum int, tc model.TestCase, sc *scope) {
+	if len(tc.Cleanup) == 0 {
+		return
+	}
+
+	r.cleanupMu.Lock()
+	defer r.cleanupMu.Unlock()
+	for _, line := range tc.Cleanup {
+		method, path, body := parseCleanup(line)
+		c := tc
+		c.Method = method
+		c.Path = sc.expand(path)
+		c.Body = sc.expand(body)
+		c.PathParams = nil
+		c.QueryParams = nil
+		r.cleanups = append(r.cleanups, cleanup{caseNum: caseNum, testCase: c, jar: sc.jar})
+	}
+}
+
+// runCleanups 按登记的逆序执行所有清理请求
+func (r *Runner) runCleanups(ctx context.Context) {
+	r.cleanupMu.Lock()
+	defer r.cleanupMu.Unlock()
+
+	for i := len(r.cleanups) - 1; i >= 0; i-- {
+		r.cleanupResults = append(r.cleanupResults, r.executeCleanup(ctx, r.cleanups[i]))
+	}
+	r.cleanups = nil
+}
+
+func (r *Runner) executeCleanup(ctx context.Context, c cleanup) model.CleanupResult {
+	startTime := time.Now()
+	tc := c.testCase
+	result := model.CleanupResult{
+		CaseNumber: c.caseNum,
+		CaseName:   tc.CaseName,
+		Sheet:      tc.Sheet,
+		Method:     tc.Method,
+		Path:       tc.Path,
+	}
+
+	// 变量未能替换说明资源很可能没有创建成功，不发送请求
+	if unresolved := varPattern.FindAllString(tc.Path+tc.Body, -1); len(unresolved) > 0 {
+		result.Error = fmt.Sprintf("变量未解析: %s", strings.Join(unresolved, ", "))
+		return result
+	}
+
+	req, err := r.buildRequest(ctx, tc)
+	if err != nil {
+		result.Error = fmt.Sprintf("创建请求失败: %v", err)
+		return result
+	}
+	result.Curl = r.toCurl(req, tc)
+
+	client, err := r.client(req.URL, tc, c.jar)
+	if err != nil {
+		result.Error = fmt.Sprintf("创建请求失败: %v", err)
+		return result
+	}
+
+	release, err := r.limiter.acquire(ctx, req.URL.Host)
+	if err != nil {
+		result.Error = fmt.Sprintf("执行请求失败: %v", err)
+		return result
+	}
+	defer release()
+
+	resp, err := client.Do(req)
+	if err != nil {
+		result.Error = fmt.Sprintf("执行请求失败: %v", err)
+		return result
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	result.StatusCode = resp.StatusCode
+	result.Success = resp.StatusCode >= 200 && resp.StatusCode < 300
+	if !result.Success {
+		result.Error = fmt.Sprintf("响应状态码: %d", resp.StatusCode)
+	}
+	result.ExecutionTime = sinceMillis(startTime)
+	return result
+}
+
+// CleanupResults 返回最近一次运行的清理结果
+func (r *Runner) CleanupResults() []model.CleanupResult {
+	r.cleanupMu.Lock()
+	defer r.cleanupMu.Unlock()
+	return r.cleanupResults
+}
+
+// parseCleanup 解析一行清理配置，格式为 "方法 路径 [请求体]"，eg: DELETE /orders/${orderId}
+func parseCleanup(line string) (method, path, body string) {
+	parts := strings.SplitN(strings.TrimSpace(line), " ", 3)
+	method = strings.ToUpper(parts[0])
+	if len(parts) > 1 {
+		path = strings.TrimSpace(parts[1])
+	}
+	if len(parts) > 2 {
+		body = strings.TrimSpace(parts[2])
+	}
+	return method, path, body
+}
+
+// parseCleanupLines 解析清理列，每行一个清理请求，忽略不是 HTTP 方法开头的行
+func parseCleanupLines(value string) []string {
+	var lines []string
+	for _, line := range strings.Split(value, "\n") {
+		line = strings.TrimSpace(line)
+		if method, path, _ := parseCleanup(line); path != "" && isHTTPMethod(method) {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
@@ -0,0 +1,80 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"regression_testing/internal/model"
+)
+
+// DryRun 按与实际执行相同的规则解析选中的用例，返回最终的请求和 curl 命令，不发送任何请求。
+// 钩子不会执行，钩子和前序步骤提取的变量保持 ${name} 原样并单独列出
+func (r *Runner) DryRun() ([]model.RequestPreview, error) {
+	sheetSteps, _, err := r.loadCases()
+	if err != nil {
+		return nil, err
+	}
+
+	var previews []model.RequestPreview
+	for _, steps := range sheetSteps {
+		for _, s := range steps {
+			previews = append(previews, r.preview(s))
+		}
+	}
+	return previews, nil
+}
+
+// preview 构建单个用例的最终请求，与 executeTest 使用相同的变量替换和请求构建逻辑
+func (r *Runner) preview(s step) model.RequestPreview {
+	tc := newScope(nil).resolve(s.testCase)
+	p := model.RequestPreview{
+		CaseNumber:  s.caseNum,
+		CaseName:    tc.CaseName,
+		Sheet:       tc.Sheet,
+		Method:      tc.Method,
+		Body:        tc.Body,
+		BaseURLFrom: tc.BaseURLFrom,
+		TokenFrom:   tc.TokenFrom,
+		Disabled:    tc.Disabled,
+	}
+
+	req, err := r.buildRequest(context.Background(), tc)
+	if err != nil {
+		p.Error = fmt.Sprintf("创建请求失败: %v", err)
+		return p
+	}
+	p.URL = req.URL.String()
+	p.Curl = r.toCurl(req, tc)
+
+	texts := []string{tc.Path, tc.Body, tc.Token}
+	for name, values := range req.Header {
+		p.Headers = append(p.Headers, name+": "+values[0])
+		texts = append(texts, values[0])
+	}
+	sort.Strings(p.Headers)
+	for _, m := range []map[string]string{tc.PathParams, tc.QueryParams} {
+		for _, v := range m {
+			texts = append(texts, v)
+		}
+	}
+	p.Unresolved = unresolvedVars(texts)
+	return p
+}
+
+// unresolvedVars 返回文本中引用的变量名，去重后排序
+func unresolvedVars(texts []string) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, text := range texts {
+		for _, m := range varPattern.FindAllStringSubmatch(text, -1) {
+			if name := strings.TrimSpace(m[1]); !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,199 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+
+	"regression_testing/internal/model"
+)
+
+// 钩子类型，写在钩子表的第一列；工作表和用例级钩子可以用 "类型:工作表" 限定生效的工作表
+const (
+	hookBeforeAll   = "before_all"   // 整个运行开始前
+	hookAfterAll    = "after_all"    // 整个运行结束后
+	hookBeforeSheet = "before_sheet" // 每个工作表执行前
+	hookAfterSheet  = "after_sheet"  // 每个工作表执行后
+	hookBeforeEach  = "before_each"  // 每个用例执行前
+	hookAfterEach   = "after_each"   // 每个用例执行后
+)
+
+// hook 表示钩子表中的一行
+type hook struct {
+	kind  string
+	sheet string // 限定的工作表，为空时对所有工作表生效
+	step  step
+}
+
+// sheetRun 保存执行一个工作表所需的上下文
+type sheetRun struct {
+	name       string
+	vars       map[string]string // 前置钩子提取的变量
+	beforeEach []hook
+	afterEach  []hook
+}
+
+// loadHooks 读取钩子表，钩子表不存在时返回空
+func (r *Runner) loadHooks(f *excelize.File) ([]hook, error) {
+	if idx, _ := f.GetSheetIndex(r.config.HooksSheet); idx == -1 {
+		return nil, nil
+	}
+
+	rows, err := f.GetRows(r.config.HooksSheet)
+	if err != nil {
+		return nil, fmt.Errorf("无法读取钩子表: %v", err)
+	}
+	if len(rows) <= 1 {
+		return nil, nil
+	}
+
+	// 钩子表第一列为钩子类型，其余列与用例表一致
+	var hooks []hook
+	inheritedBaseURL := ""
+	for i, row := range rows[1:] {
+		if len(row) < 2 {
+			continue
+		}
+		kind, sheet, _ := strings.Cut(cellAt(row, 0), ":")
+		if !isHookKind(kind) {
+			continue
+		}
+		if baseURL := cellAt(row, 10); baseURL != "" {
+			inheritedBaseURL = baseURL
+		}
+		testCase, ok := r.parseRow(row[1:], inheritedBaseURL)
+		if !ok {
+			continue
+		}
+		testCase.Sheet = r.config.HooksSheet
+		hooks = append(hooks, hook{
+			kind:  kind,
+			sheet: sheet,
+			step:  step{caseNum: i + 2, testCase: testCase},
+		})
+	}
+	return hooks, nil
+}
+
+func isHookKind(kind string) bool {
+	switch kind {
+	case hookBeforeAll, hookAfterAll, hookBeforeSheet, hookAfterSheet, hookBeforeEach, hookAfterEach:
+		return true
+	}
+	return false
+}
+
+// matchHooks 返回指定类型且对该工作表生效的钩子
+func matchHooks(hooks []hook, kind, sheet string) []hook {
+	var matched []hook
+	for _, h := range hooks {
+		if h.kind == kind && (h.sheet == "" || h.sheet == sheet) {
+			matched = append(matched, h)
+		}
+	}
+	return matched
+}
+
+// runSheets 按 before_all → (before_sheet → 用例 → after_sheet) × 工作表 → 清理 → after_all 的顺序执行，
+// 前置钩子失败时跳过对应范围内的用例，后置钩子无论用例是否失败都会执行
+func (r *Runner) runSheets(ctx context.Context, sheetSteps [][]step, hooks []hook) []model.TestResult {
+	// 后置钩子和清理在运行被取消后仍然执行，只受单个请求的超时限制
+	teardownCtx := context.WithoutCancel(ctx)
+
+	runScope := newScope(nil)
+	results := r.runHooks(ctx, matchHooks(hooks, hookBeforeAll, ""), runScope, true)
+	setupPassed := passed(results)
+
+	for i, name := range r.config.Sheets {
+		steps := sheetSteps[i]
+		if len(steps) == 0 {
+			continue
+		}
+		if ctx.Err() != nil {
+			results = append(results, skippedResults(steps, notRunReason(ctx))...)
+			continue
+		}
+		if !setupPassed {
+			results = append(results, skippedResults(steps, "全局前置钩子未通过")...)
+			continue
+		}
+
+		sheetScope := newScope(runScope.vars)
+		sheetSetup := r.runHooks(ctx, matchHooks(hooks, hookBeforeSheet, name), sheetScope, true)
+		results = append(results, sheetSetup...)
+
+		if passed(sheetSetup) {
+			sr := &sheetRun{
+				name:       name,
+				vars:       sheetScope.vars,
+				beforeEach: matchHooks(hooks, hookBeforeEach, name),
+				afterEach:  matchHooks(hooks, hookAfterEach, name),
+			}
+			results = append(results, r.schedule(ctx, r.copyJobs(mergeGroups(buildJobs(steps))), sr)...)
+		} else {
+			results = append(results, skippedResults(steps, "工作表前置钩子未通过")...)
+		}
+
+		results = append(results, r.runHooks(teardownCtx, matchHooks(hooks, hookAfterSheet, name), sheetScope, false)...)
+	}
+
+	// 先清理用例创建的资源，再执行全局后置钩子
+	if errors.Is(context.Cause(ctx), errFailureLimit) {
+		fmt.Println("失败用例数达到上限，停止执行，正在执行清理和后置钩子")
+	} else if ctx.Err() != nil {
+		fmt.Println("运行已取消，正在执行清理和后置钩子（再次中断可强制退出）")
+	}
+	r.runCleanups(teardownCtx)
+	results = append(results, r.runHooks(teardownCtx, matchHooks(hooks, hookAfterAll, ""), runScope, false)...)
+	// 全局后置钩子登记的清理请求最后执行
+	r.runCleanups(teardownCtx)
+	return results
+}
+
+// runHooks 依次执行钩子，前置钩子遇到失败即停止，后置钩子全部执行
+func (r *Runner) runHooks(ctx context.Context, hooks []hook, sc *scope, stopOnFailure bool) []model.TestResult {
+	var results []model.TestResult
+	for _, h := range hooks {
+		result := r.executeTest(ctx, h.step.caseNum, h.step.testCase, sc)
+		result.Hook = h.kind
+		results = append(results, result)
+		if !result.Passed() && stopOnFailure {
+			break
+		}
+	}
+	return results
+}
+
+// executeWithEachHooks 执行用例及其用例级钩子，只返回失败的钩子结果，避免报告被重复的钩子刷屏
+func (r *Runner) executeWithEachHooks(ctx context.Context, s step, sr *sheetRun, sc *scope) (model.TestResult, []model.TestResult) {
+	var failedHooks []model.TestResult
+	collect := func(results []model.TestResult) {
+		for _, result := range results {
+			if result.Failed() {
+				failedHooks = append(failedHooks, result)
+			}
+		}
+	}
+
+	setup := r.runHooks(ctx, sr.beforeEach, sc, true)
+	collect(setup)
+
+	var result model.TestResult
+	if passed(setup) {
+		result = r.executeTest(ctx, s.caseNum, s.testCase, sc)
+	} else if ctx.Err() != nil {
+		result = skippedResults([]step{s}, notRunReason(ctx))[0]
+	} else {
+		result = skippedResults([]step{s}, "用例前置钩子未通过")[0]
+	}
+
+	if result.Blocking() {
+		r.recordFailure()
+	}
+
+	collect(r.runHooks(context.WithoutCancel(ctx), sr.afterEach, sc, false))
+	return result, failedHooks
+}
@@ -0,0 +1,169 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"regression_testing/internal/model"
+)
+
+// loadCase 是压测中的一个用例及其统计
+type loadCase struct {
+	step step
+	vars map[string]string // 前置钩子提取的变量
+
+	mu        sync.Mutex
+	requests  int
+	errors    int
+	latencies []float64
+}
+
+// RunLoad 以目标速率或并发数在指定时长内循环发送选中的用例，统计每个用例的吞吐量、错误率和耗时分位数。
+// 压测中每个用例独立请求：不按场景和依赖关系编排，不重试、不轮询、不提取变量、不登记清理请求；
+// before_all / before_sheet 钩子提取的变量可以照常引用
+func (r *Runner) RunLoad(ctx context.Context) ([]model.LoadResult, error) {
+	sheetSteps, hooks, err := r.loadCases()
+	if err != nil {
+		return nil, err
+	}
+	defer r.closeTransports()
+
+	// 前置钩子失败时无法压测，后置钩子在压测结束后照常执行；
+	// 与 runSheets 一样，钩子登记的清理请求在全局后置钩子前后执行（defer 按逆序执行）
+	teardownCtx := context.WithoutCancel(ctx)
+	r.cleanupResults = nil
+	runScope := newScope(nil)
+	defer r.runCleanups(teardownCtx)
+	defer r.runHooks(teardownCtx, matchHooks(hooks, hookAfterAll, ""), runScope, false)
+	defer r.runCleanups(teardownCtx)
+	if !passed(r.runHooks(ctx, matchHooks(hooks, hookBeforeAll, ""), runScope, true)) {
+		return nil, fmt.Errorf("全局前置钩子未通过，无法压测")
+	}
+
+	var cases []*loadCase
+	for i, name := range r.config.Sheets {
+		if len(sheetSteps[i]) == 0 {
+			continue
+		}
+		sheetScope := newScope(runScope.vars)
+		defer r.runHooks(teardownCtx, matchHooks(hooks, hookAfterSheet, name), sheetScope, false)
+		if !passed(r.runHooks(ctx, matchHooks(hooks, hookBeforeSheet, name), sheetScope, true)) {
+			return nil, fmt.Errorf("工作表 %s 的前置钩子未通过，无法压测", name)
+		}
+		for _, s := range sheetSteps[i] {
+			if !s.testCase.Disabled {
+				cases = append(cases, &loadCase{step: s, vars: sheetScope.vars})
+			}
+		}
+	}
+	if len(cases) == 0 {
+		return nil, fmt.Errorf("没有可以压测的用例")
+	}
+
+	cfg := r.config.LoadTest
+	concurrency := r.concurrency()
+	if cfg.RPS > 0 {
+		fmt.Printf("开始压测: %d 个用例, 目标 %.0f 请求/秒, 并发 %d, 持续 %s\n", len(cases), cfg.RPS, concurrency, cfg.Duration)
+	} else {
+		fmt.Printf("开始压测: %d 个用例, 并发 %d, 持续 %s\n", len(cases), concurrency, cfg.Duration)
+	}
+
+	loadCtx, cancel := context.WithTimeout(ctx, cfg.Duration)
+	defer cancel()
+	bucket := newTokenBucket(cfg.RPS, concurrency) // 桶容量与并发数一致，避免等待误差拉低实际速率
+	var next atomic.Int64
+	var wg sync.WaitGroup
+
+	startTime := time.Now()
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for bucket.wait(loadCtx) == nil && loadCtx.Err() == nil {
+				c := cases[int(next.Add(1)-1)%len(cases)]
+				r.loadRequest(loadCtx, c)
+			}
+		}()
+	}
+	wg.Wait()
+	elapsed := time.Since(startTime)
+
+	results := make([]model.LoadResult, 0, len(cases))
+	for _, c := range cases {
+		results = append(results, c.result(elapsed))
+	}
+	return results, nil
+}
+
+// loadRequest 发送一次请求并记录结果，压测结束时被中断的请求不计入统计
+func (r *Runner) loadRequest(ctx context.Context, c *loadCase) {
+	sc := newScope(c.vars)
+	tc := sc.resolve(c.step.testCase)
+	resp, err := r.send(ctx, tc, sc)
+	if err != nil && ctx.Err() != nil {
+		return
+	}
+	success := err == nil && r.matches(tc, resp)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.requests++
+	if !success {
+		c.errors++
+	}
+	c.latencies = append(c.latencies, resp.latency)
+}
+
+// result 汇总用例的压测结果
+func (c *loadCase) result(elapsed time.Duration) model.LoadResult {
+	tc := c.step.testCase
+	result := model.LoadResult{
+		CaseNumber: c.step.caseNum,
+		CaseName:   tc.CaseName,
+		Sheet:      tc.Sheet,
+		Method:     tc.Method,
+		Path:       tc.Path,
+		Requests:   c.requests,
+		Errors:     c.errors,
+	}
+	if elapsed > 0 {
+		result.Throughput = float64(c.requests) / elapsed.Seconds()
+	}
+	if len(c.latencies) == 0 {
+		return result
+	}
+
+	sort.Float64s(c.latencies)
+	sum := 0.0
+	for _, l := range c.latencies {
+		sum += l
+	}
+	result.Mean = sum / float64(len(c.latencies))
+	result.P50 = percentile(c.latencies, 50)
+	result.P90 = percentile(c.latencies, 90)
+	result.P99 = percentile(c.latencies, 99)
+	result.Max = c.latencies[len(c.latencies)-1]
+	return result
+}
+
+// percentile 按最近秩法计算已排序数据的分位数
+func percentile(sorted []float64, p float64) float64 {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// concurrency 返回本次运行的并发数：压测时为压测并发数，未指定时与普通运行一样使用 concurrent
+func (r *Runner) concurrency() int {
+	if r.config.LoadTest.Enabled() && r.config.LoadTest.Concurrency > 0 {
+		return r.config.LoadTest.Concurrency
+	}
+	return r.config.Concurrent
+}
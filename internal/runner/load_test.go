@@ -0,0 +1,30 @@
+package runner
+
+import "testing"
+
+func TestPercentile(t *testing.T) {
+	sorted := []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	tests := []struct {
+		name   string
+		sorted []float64
+		p      float64
+		want   float64
+	}{
+		{name: "P0 取最小值", sorted: sorted, p: 0, want: 1},
+		{name: "P10", sorted: sorted, p: 10, want: 1},
+		{name: "P50", sorted: sorted, p: 50, want: 5},
+		{name: "P51 向上取秩", sorted: sorted, p: 51, want: 6},
+		{name: "P90", sorted: sorted, p: 90, want: 9},
+		{name: "P99", sorted: sorted, p: 99, want: 10},
+		{name: "P100 取最大值", sorted: sorted, p: 100, want: 10},
+		{name: "单个样本", sorted: []float64{42}, p: 99, want: 42},
+		{name: "两个样本的 P50", sorted: []float64{1, 3}, p: 50, want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := percentile(tt.sorted, tt.p); got != tt.want {
+				t.Errorf("percentile(%v, %v) = %v, want %v", tt.sorted, tt.p, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"regression_testing/internal/model"
+)
+
+// 轮询默认值
+const (
+	defaultPollInterval = time.Second
+	defaultPollMaxWait  = 30 * time.Second
+)
+
+// poll 反复发送请求，直到响应与期望结果匹配或超过最长等待时间，
+// 适用于异步导出、支付回调等最终一致的接口
+func (r *Runner) poll(ctx context.Context, tc model.TestCase, sc *scope, result *model.TestResult) {
+	startTime := time.Now()
+	deadline := startTime.Add(tc.Poll.MaxWait)
+
+	for {
+		resp, err := r.send(ctx, tc, sc)
+		result.Polls++
+		result.StatusCode = resp.statusCode
+		result.Redirects = resp.redirects
+		result.ExecutionTime = resp.latency
+
+		if err != nil {
+			result.Status = model.StatusError
+			result.Error = err.Error()
+		} else {
+			result.ActualResult = resp.body
+			result.Status = model.StatusFailed
+			if r.matches(tc, resp) {
+				result.Status = model.StatusPassed
+			}
+			result.Error = ""
+		}
+
+		if result.Passed() {
+			result.ConvergenceTime = sinceMillis(startTime)
+			return
+		}
+		if time.Now().Add(tc.Poll.Interval).After(deadline) {
+			if result.Error == "" {
+				result.Error = fmt.Sprintf("等待 %s 后仍未达到期望结果（轮询 %d 次）", tc.Poll.MaxWait, result.Polls)
+			}
+			return
+		}
+		if sleepContext(ctx, tc.Poll.Interval) != nil {
+			return
+		}
+	}
+}
+
+// parsePoll 解析轮询列，格式为 {"interval": "1s", "max_wait": "30s"}，无法解析时忽略
+func parsePoll(value string) *model.PollPolicy {
+	if value == "" {
+		return nil
+	}
+
+	var raw struct {
+		Interval string `json:"interval"`
+		MaxWait  string `json:"max_wait"`
+	}
+	if err := json.Unmarshal([]byte(value), &raw); err != nil {
+		return nil
+	}
+
+	policy := &model.PollPolicy{Interval: defaultPollInterval, MaxWait: defaultPollMaxWait}
+	if d, err := time.ParseDuration(raw.Interval); err == nil && d > 0 {
+		policy.Interval = d
+	}
+	if d, err := time.ParseDuration(raw.MaxWait); err == nil && d > 0 {
+		policy.MaxWait = d
+	}
+	return policy
+}
@@ -0,0 +1,81 @@
+package runner
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"regression_testing/internal/config"
+)
+
+// proxyFunc 返回 Transport 使用的代理选择函数，未配置代理时使用环境变量
+func proxyFunc(cfg config.Proxy) (func(*http.Request) (*url.URL, error), error) {
+	if cfg.URL == "" {
+		return http.ProxyFromEnvironment, nil
+	}
+	proxyURL, err := parseProxyURL(cfg.URL)
+	if err != nil {
+		return nil, err
+	}
+	return func(req *http.Request) (*url.URL, error) {
+		if bypassProxy(req.URL, cfg.NoProxy) {
+			return nil, nil
+		}
+		return proxyURL, nil
+	}, nil
+}
+
+// proxyFor 返回请求实际使用的代理地址，不走代理时返回空
+func proxyFor(cfg config.Proxy, u *url.URL) string {
+	if cfg.URL == "" || bypassProxy(u, cfg.NoProxy) {
+		return ""
+	}
+	return cfg.URL
+}
+
+func parseProxyURL(value string) (*url.URL, error) {
+	u, err := url.Parse(value)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("代理地址无效: %s", value)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5":
+		return u, nil
+	}
+	return nil, fmt.Errorf("不支持的代理协议: %s", u.Scheme)
+}
+
+// bypassProxy 判断主机是否匹配 no_proxy 规则：
+// * 匹配所有主机，CIDR 匹配 IP，.example.com 和 example.com 都匹配该域名及其子域名，带端口的规则还需端口一致
+func bypassProxy(u *url.URL, noProxy []string) bool {
+	host, port := u.Hostname(), u.Port()
+	ip := net.ParseIP(host)
+	for _, rule := range noProxy {
+		rule = strings.ToLower(strings.TrimSpace(rule))
+		if rule == "" {
+			continue
+		}
+		if rule == "*" {
+			return true
+		}
+		if _, cidr, err := net.ParseCIDR(rule); err == nil {
+			if ip != nil && cidr.Contains(ip) {
+				return true
+			}
+			continue
+		}
+		if h, p, err := net.SplitHostPort(rule); err == nil {
+			if p != port {
+				continue
+			}
+			rule = h
+		}
+		domain := strings.TrimPrefix(rule, ".")
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,133 @@
+package runner
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"regression_testing/internal/config"
+)
+
+// tokenBucket 是令牌桶限流器，rate 为每秒补充的令牌数，burst 为桶容量
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	if rate <= 0 {
+		return nil
+	}
+	return &tokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// wait 阻塞直到取得一个令牌或 ctx 被取消，限流器为空时直接返回
+func (b *tokenBucket) wait(ctx context.Context) error {
+	if b == nil {
+		return nil
+	}
+	for {
+		b.mu.Lock()
+		now := time.Now()
+		b.tokens += now.Sub(b.last).Seconds() * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+		b.last = now
+		if b.tokens >= 1 {
+			b.tokens--
+			b.mu.Unlock()
+			return nil
+		}
+		delay := time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+		b.mu.Unlock()
+		if err := sleepContext(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// hostLimiter 限制单个目标主机的请求速率和并发数
+type hostLimiter struct {
+	bucket   *tokenBucket
+	inflight chan struct{}
+}
+
+// rateLimiter 在所有工作协程之间共享，按全局和目标主机两级限流
+type rateLimiter struct {
+	cfg    config.RateLimit
+	global *tokenBucket
+
+	mu    sync.Mutex
+	hosts map[string]*hostLimiter
+}
+
+func newRateLimiter(cfg config.RateLimit) *rateLimiter {
+	return &rateLimiter{
+		cfg:    cfg,
+		global: newTokenBucket(cfg.QPS, cfg.Burst),
+		hosts:  make(map[string]*hostLimiter),
+	}
+}
+
+func (l *rateLimiter) host(host string) *hostLimiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	h, ok := l.hosts[host]
+	if !ok {
+		h = &hostLimiter{bucket: newTokenBucket(l.cfg.PerHostQPS, l.cfg.PerHostBurst)}
+		if l.cfg.PerHostMaxInFlight > 0 {
+			h.inflight = make(chan struct{}, l.cfg.PerHostMaxInFlight)
+		}
+		l.hosts[host] = h
+	}
+	return h
+}
+
+// acquire 等待发往 host 的请求许可，返回的函数用于在请求结束后释放并发名额
+func (l *rateLimiter) acquire(ctx context.Context, host string) (func(), error) {
+	h := l.host(host)
+	release := func() {
+		if h.inflight != nil {
+			<-h.inflight
+		}
+	}
+
+	if h.inflight != nil {
+		select {
+		case h.inflight <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if err := h.bucket.wait(ctx); err != nil {
+		release()
+		return nil, err
+	}
+	if err := l.global.wait(ctx); err != nil {
+		release()
+		return nil, err
+	}
+	return release, nil
+}
+
+// sleepContext 等待 d 或直到 ctx 被取消
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,110 @@
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"regression_testing/internal/model"
+)
+
+// 未配置重定向次数时最多跟随的次数，与 http.Client 的默认值一致
+const defaultMaxRedirects = 10
+
+// checkRedirect 返回记录重定向并按策略决定是否跟随的 CheckRedirect 函数
+func checkRedirect(policy *model.RedirectPolicy, hops *[]model.Redirect) func(*http.Request, []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		if req.Response != nil {
+			*hops = append(*hops, model.Redirect{
+				StatusCode: req.Response.StatusCode,
+				Location:   req.Response.Header.Get("Location"),
+			})
+		}
+
+		maxHops := defaultMaxRedirects
+		if policy != nil {
+			if !policy.Follow {
+				return http.ErrUseLastResponse // 不跟随，直接返回重定向响应
+			}
+			if policy.MaxHops > 0 {
+				maxHops = policy.MaxHops
+			}
+		}
+		if len(via) > maxHops {
+			return fmt.Errorf("重定向次数超过上限 %d", maxHops)
+		}
+		return nil
+	}
+}
+
+// matches 判断响应是否符合重定向断言和期望结果；
+// 配置了重定向断言且期望结果为空时，只校验重定向
+func (r *Runner) matches(tc model.TestCase, resp response) bool {
+	if tc.Redirect != nil && tc.Redirect.Asserts() {
+		if !r.validateRedirect(*tc.Redirect, resp.redirects) {
+			return false
+		}
+		if tc.Expected == "" {
+			return true
+		}
+	}
+	return r.validateResponse(resp.body, tc.Expected, tc.StrictMatch)
+}
+
+// validateRedirect 校验第一次重定向的状态码和 Location
+func (r *Runner) validateRedirect(policy model.RedirectPolicy, hops []model.Redirect) bool {
+	if len(hops) == 0 {
+		return false
+	}
+	if policy.Status != 0 && hops[0].StatusCode != policy.Status {
+		return false
+	}
+	if policy.Location != "" && !r.validateValue(hops[0].Location, policy.Location) {
+		return false
+	}
+	return true
+}
+
+// curlRedirectFlags 返回与重定向策略对应的 curl 参数；未配置时与实际请求一样最多跟随 10 次
+func curlRedirectFlags(policy *model.RedirectPolicy) string {
+	if policy != nil && !policy.Follow {
+		return ""
+	}
+	maxHops := defaultMaxRedirects
+	if policy != nil && policy.MaxHops > 0 {
+		maxHops = policy.MaxHops
+	}
+	return fmt.Sprintf(" -L --max-redirs %d", maxHops)
+}
+
+// parseRedirect 解析重定向列，eg: {"follow": false, "status": 302, "location": "^https://sso/"}、{"max_hops": 3}，
+// 填写 FALSE / 否 / 0 表示不跟随，无法解析时忽略
+func parseRedirect(value string) *model.RedirectPolicy {
+	if value == "" {
+		return nil
+	}
+	if isDisabled(value) {
+		return &model.RedirectPolicy{}
+	}
+
+	var raw struct {
+		Follow   *bool  `json:"follow"`
+		MaxHops  int    `json:"max_hops"`
+		Status   int    `json:"status"`
+		Location string `json:"location"`
+	}
+	if err := json.Unmarshal([]byte(value), &raw); err != nil {
+		return nil
+	}
+
+	policy := &model.RedirectPolicy{
+		Follow:   true,
+		MaxHops:  raw.MaxHops,
+		Status:   raw.Status,
+		Location: raw.Location,
+	}
+	if raw.Follow != nil {
+		policy.Follow = *raw.Follow
+	}
+	return policy
+}
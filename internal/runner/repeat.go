@@ -0,0 +1,66 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"regression_testing/internal/model"
+)
+
+// runRepeated 将选中的用例完整执行 N 次，每次都执行钩子和清理，结果中记录第几次执行
+func (r *Runner) runRepeated(ctx context.Context, sheetSteps [][]step, hooks []hook) []model.TestResult {
+	var results []model.TestResult
+	for i := 1; i <= r.config.Repeat; i++ {
+		if ctx.Err() != nil {
+			break
+		}
+		fmt.Printf("第 %d / %d 次执行\n", i, r.config.Repeat)
+		for _, result := range r.runSheets(ctx, sheetSteps, hooks) {
+			result.Iteration = i
+			results = append(results, result)
+		}
+	}
+	return results
+}
+
+// copyJobs 为并发重复执行复制调度单元，每份副本有独立的序号，
+// 副本之间互不依赖，一起交给工作协程并发执行
+func (r *Runner) copyJobs(jobs []job) []job {
+	if r.copies <= 1 {
+		return jobs
+	}
+	copies := make([]job, 0, len(jobs)*r.copies)
+	for i := 1; i <= r.copies; i++ {
+		for _, j := range jobs {
+			j.iteration = i
+			copies = append(copies, j)
+		}
+	}
+	return copies
+}
+
+// newShuffle 创建打乱执行顺序用的随机数，未指定种子时使用当前时间并输出，便于复现
+func newShuffle(seed int64) *rand.Rand {
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	fmt.Printf("随机执行顺序，种子: %d（使用 --seed 复现）\n", seed)
+	return rand.New(rand.NewSource(seed))
+}
+
+// dispatchOrder 返回调度单元的派发顺序，打乱执行顺序时随机排列；
+// 只影响执行的先后，报告中的结果仍按行顺序排列
+func (r *Runner) dispatchOrder(n int) []int {
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	if r.shuffle != nil {
+		r.shuffle.Shuffle(n, func(i, j int) {
+			order[i], order[j] = order[j], order[i]
+		})
+	}
+	return order
+}
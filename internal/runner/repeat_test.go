@@ -0,0 +1,58 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+
+	"regression_testing/internal/config"
+)
+
+func TestScheduleConcurrentCopies(t *testing.T) {
+	srv := newTestServer(t)
+	r := newTestRunner(&config.Config{Concurrent: 4})
+	r.copies = 3
+
+	jobs := r.copyJobs(buildJobs(buildSteps(srv.URL, []testCase{
+		{row: 2, name: "a"},
+		{row: 3, name: "b", dependsOn: []string{"a"}},
+	})))
+	results := r.schedule(context.Background(), jobs, &sheetRun{name: "Sheet1"})
+
+	runs := make(map[int][]int) // 行号 -> 执行的副本序号
+	for _, result := range results {
+		if !result.Passed() {
+			t.Errorf("row %d copy %d: status = %s (%s)", result.CaseNumber, result.Iteration, result.Status, result.Error)
+		}
+		runs[result.CaseNumber] = append(runs[result.CaseNumber], result.Iteration)
+	}
+	for _, row := range []int{2, 3} {
+		sort.Ints(runs[row])
+		if !reflect.DeepEqual(runs[row], []int{1, 2, 3}) {
+			t.Errorf("row %d ran copies %v, want [1 2 3]", row, runs[row])
+		}
+	}
+}
+
+func TestScheduleShuffleKeepsReportOrder(t *testing.T) {
+	srv := newTestServer(t)
+	r := newTestRunner(&config.Config{Concurrent: 1, Sheets: []string{"Sheet1"}})
+	r.shuffle = rand.New(rand.NewSource(42))
+
+	var cases []testCase
+	for row := 2; row <= 9; row++ {
+		cases = append(cases, testCase{row: row, name: fmt.Sprintf("case%d", row)})
+	}
+	results := r.runSheets(context.Background(), [][]step{buildSteps(srv.URL, cases)}, nil)
+
+	var rows []int
+	for _, result := range results {
+		rows = append(rows, result.CaseNumber)
+	}
+	if want := []int{2, 3, 4, 5, 6, 7, 8, 9}; !reflect.DeepEqual(rows, want) {
+		t.Errorf("report rows = %v, want %v", rows, want)
+	}
+}
@@ -0,0 +1,82 @@
+package runner
+
+import (
+	"context"
+
+	"regression_testing/internal/config"
+	"regression_testing/internal/model"
+)
+
+// sendWithRetry 发送请求并按重试策略重试，记录每一次请求
+func (r *Runner) sendWithRetry(ctx context.Context, tc model.TestCase, sc *scope, result *model.TestResult) {
+	policy := r.retryPolicy(tc)
+	for attempt := 1; ; attempt++ {
+		resp, err := r.send(ctx, tc, sc)
+		a := model.Attempt{Number: attempt, StatusCode: resp.statusCode, Latency: resp.latency}
+
+		condition := ""
+		result.Status = model.StatusFailed
+		if err != nil {
+			a.Error = err.Error()
+			condition = model.RetryOnNetwork
+			result.Status = model.StatusError
+		} else {
+			result.ActualResult = resp.body
+			a.Success = r.matches(tc, resp)
+			if resp.statusCode >= 500 {
+				condition = model.RetryOn5xx
+			} else {
+				condition = model.RetryOnAssertion
+			}
+		}
+
+		result.Attempts = append(result.Attempts, a)
+		result.StatusCode = resp.statusCode
+		result.Redirects = resp.redirects
+		if a.Success {
+			result.Status = model.StatusPassed
+		}
+		result.Error = a.Error
+		result.ExecutionTime = a.Latency
+
+		if a.Success || attempt >= policy.MaxAttempts || !policy.RetryOn(condition) {
+			break
+		}
+		if sleepContext(ctx, policy.Delay(attempt)) != nil {
+			break
+		}
+	}
+}
+
+// retryPolicy 合并全局重试策略和用例级重试策略，用例中填写的字段优先
+func (r *Runner) retryPolicy(tc model.TestCase) model.RetryPolicy {
+	policy := r.config.Retry
+	if tc.Retry == nil {
+		return policy
+	}
+	if tc.Retry.MaxAttempts > 0 {
+		policy.MaxAttempts = tc.Retry.MaxAttempts
+	}
+	if tc.Retry.Backoff > 0 {
+		policy.Backoff = tc.Retry.Backoff
+	}
+	if tc.Retry.MaxBackoff > 0 {
+		policy.MaxBackoff = tc.Retry.MaxBackoff
+	}
+	if len(tc.Retry.On) > 0 {
+		policy.On = tc.Retry.On
+	}
+	return policy
+}
+
+// parseRetry 解析重试列，格式与配置文件中的 retry 一致，无法解析时忽略
+func parseRetry(value string) *model.RetryPolicy {
+	if value == "" {
+		return nil
+	}
+	policy, err := config.ParseRetryPolicy(value)
+	if err != nil {
+		return nil
+	}
+	return &policy
+}
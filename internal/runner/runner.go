@@ -2,14 +2,18 @@ package runner
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"math/rand"
 	"net/http"
 	"reflect"
 	"regexp"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/xuri/excelize/v2"
@@ -18,110 +22,192 @@ import (
 	"regression_testing/internal/model"
 )
 
+// 扩展列索引（从 0 开始，位于 GlobalHeaders 之后）
+const (
+	colDependsOn  = 12 // 依赖用例
+	colScenario   = 13 // 场景
+	colExtract    = 14 // 提取变量
+	colCleanup    = 15 // 清理请求
+	colRetry      = 16 // 重试策略
+	colPoll       = 17 // 轮询
+	colTags       = 18 // 标签
+	colPriority   = 19 // 优先级
+	colEnabled    = 20 // 是否启用
+	colSkip       = 21 // 跳过原因
+	colKnown      = 22 // 已知缺陷
+	colQuarantine = 23 // 隔离
+	colTimeout    = 24 // 请求超时
+	colMaxLatency = 25 // 最大耗时
+	colRedirect   = 26 // 重定向
+	colGroup      = 27 // 分组
+)
+
+// base-url 和 token 的来源，用于 --dry-run 输出
+const (
+	sourceRow       = "本行"
+	sourceAbove     = "上方行"
+	sourceFirstCase = "首个用例"
+	sourceConfig    = "配置文件"
+)
+
 type Runner struct {
 	config        *config.Config
 	firstToken    string
 	globalHeaders map[string]string
+	limiter       *rateLimiter
+	transports    transportPool
+	shuffle       *rand.Rand // 打乱执行顺序，为空时按行顺序派发
+	copies        int        // 并发重复执行时每个调度单元的副本数
+
+	failures atomic.Int64            // 本次运行的失败用例数
+	stopRun  context.CancelCauseFunc // 停止本次运行
+
+	cleanupMu      sync.Mutex
+	cleanups       []cleanup
+	cleanupResults []model.CleanupResult
 }
 
 func New(cfg *config.Config, _ string) *Runner {
 	return &Runner{
 		config:        cfg,
 		globalHeaders: make(map[string]string),
+		limiter:       newRateLimiter(cfg.RateLimit),
 	}
 }
 
-func (r *Runner) Run() ([]model.TestResult, error) {
-	startTime := time.Now() // 添加开始时间记录
-
-	f, err := excelize.OpenFile(r.config.ExcelPath)
+// Run 执行所有用例；ctx 被取消时停止派发新用例并取消进行中的请求，
+// 未执行的用例标记为未执行，已完成的结果照常返回
+func (r *Runner) Run(ctx context.Context) ([]model.TestResult, error) {
+	sheetSteps, hooks, err := r.loadCases()
 	if err != nil {
-		return nil, fmt.Errorf("无法打开Excel文件: %v", err)
+		return nil, err
 	}
-	defer f.Close()
+	r.cleanupResults = nil
 
-	rows, err := f.GetRows(r.config.SheetName)
-	if err != nil {
-		return nil, fmt.Errorf("无法读取工作表: %v", err)
-	}
+	// 失败数达到上限时通过取消 ctx 停止派发
+	ctx, r.stopRun = context.WithCancelCause(ctx)
+	defer r.stopRun(nil)
+	r.failures.Store(0)
+	defer r.closeTransports()
 
-	// 跳过第一行
-	if len(rows) <= 1 {
-		return nil, fmt.Errorf("没有找到测试用例")
+	if r.config.Shuffle {
+		r.shuffle = newShuffle(r.config.Seed)
 	}
-	testCases := rows[1:]
 
-	// 初始化全局配置（从第一个有效测试用例获取）
-	r.initGlobalConfig(testCases)
-
-	resultChan := make(chan model.TestResult, len(testCases))
-	var wg sync.WaitGroup
-
-	jobChan := make(chan struct {
-		caseNum int
-		row     []string
-	}, len(testCases))
+	// 按钩子、场景和依赖关系调度执行
+	var results []model.TestResult
+	switch {
+	case r.config.Repeat > 1 && r.config.RepeatConcurrent:
+		fmt.Printf("并发执行 %d 份副本\n", r.config.Repeat)
+		r.copies = r.config.Repeat
+		results = r.runSheets(ctx, sheetSteps, hooks)
+	case r.config.Repeat > 1:
+		results = r.runRepeated(ctx, sheetSteps, hooks)
+	default:
+		results = r.runSheets(ctx, sheetSteps, hooks)
+	}
+	return results, nil
+}
 
-	// 启动工作协程
-	for i := 0; i < r.config.Concurrent; i++ {
-		go r.worker(jobChan, resultChan, &wg)
+// loadCases 读取并解析所有用例工作表，按筛选条件和分片选择用例，并读取钩子
+func (r *Runner) loadCases() ([][]step, []hook, error) {
+	f, err := excelize.OpenFile(r.config.ExcelPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("无法打开Excel文件: %v", err)
 	}
+	defer f.Close()
 
-	// 分发任务
-	totalTests := 0
-	for i, row := range testCases {
-		if _, ok := r.parseRow(row); ok {
-			wg.Add(1)
-			totalTests++
-			rowNum := i + 2
-			jobChan <- struct {
-				caseNum int
-				row     []string
-			}{rowNum, row}
+	// 读取所有用例工作表（跳过第一行）
+	sheetRows := make([][][]string, len(r.config.Sheets))
+	var allRows [][]string
+	for i, name := range r.config.Sheets {
+		rows, err := f.GetRows(name)
+		if err != nil {
+			return nil, nil, fmt.Errorf("无法读取工作表 %s: %v", name, err)
+		}
+		if len(rows) > 1 {
+			sheetRows[i] = rows[1:]
+			allRows = append(allRows, rows[1:]...)
 		}
 	}
-	close(jobChan)
 
-	wg.Wait()
-	close(resultChan)
+	// 初始化全局配置（从第一个有效测试用例获取）
+	r.initGlobalConfig(allRows)
 
-	var results []model.TestResult
-	failedTests := 0
-	for result := range resultChan {
-		results = append(results, result)
-		if !result.Success {
-			failedTests++
+	// 解析所有用例
+	sheetSteps := make([][]step, len(sheetRows))
+	totalTests := 0
+	for i, rows := range sheetRows {
+		sheetSteps[i] = r.parseSheet(r.config.Sheets[i], rows)
+		totalTests += len(sheetSteps[i])
+	}
+	if totalTests == 0 {
+		return nil, nil, fmt.Errorf("没有找到测试用例")
+	}
+
+	// 按筛选条件选择用例
+	selector, err := newSelector(r.config.Filter)
+	if err != nil {
+		return nil, nil, err
+	}
+	if selector != nil {
+		selected := 0
+		for i, steps := range sheetSteps {
+			sheetSteps[i] = selector.selectSteps(steps)
+			selected += len(sheetSteps[i])
+		}
+		fmt.Printf("已选择用例: %d / %d\n", selected, totalTests)
+		if selected == 0 {
+			return nil, nil, fmt.Errorf("没有符合筛选条件的测试用例")
 		}
 	}
 
-	r.sortResults(results)
+	// 按分片选择用例，钩子在每个分片中都会执行
+	if r.config.Shard.Enabled() {
+		before := countSteps(sheetSteps)
+		sheetSteps = shardSteps(sheetSteps, r.config.Shard)
+		fmt.Printf("分片 %s: 用例 %d / %d\n", r.config.Shard, countSteps(sheetSteps), before)
+	}
 
-	// 输出测试汇总
-	fmt.Printf("\n测试汇总\n")
-	fmt.Printf("总执行时间: %.6fms\n", float64(time.Since(startTime).Microseconds())/1000)
-	fmt.Printf("总用例数: %d\n", totalTests)
-	if failedTests > 0 {
-		fmt.Printf("\033[31m失败用例数: %d\033[0m\n", failedTests)
-	} else {
-		fmt.Printf("失败用例数: %d\n", failedTests)
+	hooks, err := r.loadHooks(f)
+	if err != nil {
+		return nil, nil, err
 	}
+	return sheetSteps, hooks, nil
+}
 
-	return results, nil
+func (r *Runner) worker(ctx context.Context, jobs []job, sr *sheetRun, queue <-chan int, results chan<- jobResult) {
+	for idx := range queue {
+		// 运行已取消时，队列中尚未开始的用例不再执行
+		if ctx.Err() != nil {
+			results <- jobResult{index: idx, results: skippedResults(jobs[idx].steps, notRunReason(ctx))}
+			continue
+		}
+		progress := func(caseNum int, stepResults []model.TestResult) {
+			results <- jobResult{index: idx, caseNum: caseNum, results: stepResults}
+		}
+		results <- jobResult{index: idx, results: r.runJob(ctx, jobs[idx], sr, progress)}
+	}
 }
 
-func (r *Runner) worker(jobs <-chan struct {
-	caseNum int
-	row     []string
-}, results chan<- model.TestResult, wg *sync.WaitGroup) {
-	for job := range jobs {
-		testCase, _ := r.parseRow(job.row)
-		result := r.executeTest(job.caseNum, testCase)
-		results <- result
-		wg.Done()
+// parseSheet 解析工作表中的用例，未填写 base-url 的用例沿用上方最近一行的 base-url
+func (r *Runner) parseSheet(sheet string, rows [][]string) []step {
+	var steps []step
+	inheritedBaseURL := ""
+	for i, row := range rows {
+		if baseURL := cellAt(row, 9); baseURL != "" {
+			inheritedBaseURL = baseURL
+		}
+		if testCase, ok := r.parseRow(row, inheritedBaseURL); ok {
+			testCase.Sheet = sheet
+			rowNum := i + 2
+			steps = append(steps, step{caseNum: rowNum, testCase: testCase})
+		}
 	}
+	return steps
 }
 
-func (r *Runner) parseRow(row []string) (model.TestCase, bool) {
+func (r *Runner) parseRow(row []string, inheritedBaseURL string) (model.TestCase, bool) {
 	// 1. 基础检查：空行
 	if len(row) == 0 {
 		return model.TestCase{}, false
@@ -154,25 +240,25 @@ func (r *Runner) parseRow(row []string) (model.TestCase, bool) {
 	}
 
 	// 5. 设置基础 URL
-	baseURL := ""
+	baseURL, baseURLFrom := "", ""
 	if len(row) >= 10 && row[9] != "" {
-		baseURL = row[9] // 使用当前行的 base-url
+		baseURL, baseURLFrom = row[9], sourceRow // 使用当前行的 base-url
 	} else {
-		baseURL = r.findFirstBaseURL(row[0])
+		baseURL, baseURLFrom = inheritedBaseURL, sourceAbove
 	}
 	if baseURL == "" {
-		baseURL = r.config.BaseURL // 如果找不到，使用配置中的默认值
+		baseURL, baseURLFrom = r.config.BaseURL, sourceConfig // 如果找不到，使用配置中的默认值
 	}
 
 	// 6. 设置认证信息
-	token := ""
+	token, tokenFrom := "", ""
 	if len(row) >= 11 && row[10] != "" {
-		token = row[10] // 使用当前行的 token
+		token, tokenFrom = row[10], sourceRow // 使用当前行的 token
 	} else {
-		token = r.firstToken // 使用第一个用例的 token
+		token, tokenFrom = r.firstToken, sourceFirstCase // 使用第一个用例的 token
 	}
 	if token == "" {
-		token = r.config.Authorization // 如果都没有，使用配置中的默认值
+		token, tokenFrom = r.config.Authorization, sourceConfig // 如果都没有，使用配置中的默认值
 	}
 
 	// 7. 构建并返回测试用例
@@ -187,7 +273,25 @@ func (r *Runner) parseRow(row []string) (model.TestCase, bool) {
 		Expected:    row[7],
 		StrictMatch: row[8] == "true",
 		BaseURL:     baseURL,
+		BaseURLFrom: baseURLFrom,
 		Token:       token,
+		TokenFrom:   tokenFrom,
+		DependsOn:   parseDependsOn(cellAt(row, colDependsOn)),
+		Scenario:    cellAt(row, colScenario),
+		Group:       cellAt(row, colGroup),
+		Extract:     parseExtract(cellAt(row, colExtract)),
+		Cleanup:     parseCleanupLines(cellAt(row, colCleanup)),
+		Retry:       parseRetry(cellAt(row, colRetry)),
+		Poll:        parsePoll(cellAt(row, colPoll)),
+		Tags:        splitList(cellAt(row, colTags)),
+		Priority:    strings.ToUpper(cellAt(row, colPriority)),
+		Disabled:    isDisabled(cellAt(row, colEnabled)),
+		SkipReason:  cellAt(row, colSkip),
+		KnownIssue:  cellAt(row, colKnown),
+		Quarantine:  cellAt(row, colQuarantine),
+		Timeout:     parseDuration(cellAt(row, colTimeout)),
+		MaxLatency:  parseDuration(cellAt(row, colMaxLatency)),
+		Redirect:    parseRedirect(cellAt(row, colRedirect)),
 	}, true
 }
 
@@ -207,19 +311,10 @@ func (r *Runner) parseParams(paramStr string) map[string]string {
 	return params
 }
 
-func (r *Runner) sortResults(results []model.TestResult) {
-	for i := 0; i < len(results)-1; i++ {
-		for j := i + 1; j < len(results); j++ {
-			if results[i].CaseNumber > results[j].CaseNumber {
-				results[i], results[j] = results[j], results[i]
-			}
-		}
-	}
-}
-
 // 其他私有方法
-func (r *Runner) executeTest(caseNumber int, tc model.TestCase) model.TestResult {
-	startTime := time.Now() // 记录开始时间
+func (r *Runner) executeTest(ctx context.Context, caseNumber int, tc model.TestCase, sc *scope) model.TestResult {
+	// 替换场景变量
+	tc = sc.resolve(tc)
 
 	// 构建基本结果
 	result := model.TestResult{
@@ -231,10 +326,125 @@ func (r *Runner) executeTest(caseNumber int, tc model.TestCase) model.TestResult
 		QueryParams:    tc.QueryParams,
 		RequestBody:    tc.Body,
 		ExpectedResult: tc.Expected,
+		Scenario:       tc.Scenario,
+		Sheet:          tc.Sheet,
+		KnownIssue:     tc.KnownIssue,
+		Quarantine:     tc.Quarantine,
+	}
+
+	// 创建请求
+	req, err := r.buildRequest(ctx, tc)
+	if err != nil {
+		result.Status = model.StatusError
+		result.Error = fmt.Sprintf("创建请求失败: %v", err)
+		return result
+	}
+
+	// 生成 curl 命令
+	result.Curl = r.toCurl(req, tc)
+
+	// 请求发出后登记清理请求，此时已提取的变量可以在清理请求中引用
+	defer r.registerCleanups(caseNumber, tc, sc)
+
+	// 执行请求：轮询用例反复请求直到达到期望结果，其余用例按重试策略重试
+	if tc.Poll != nil {
+		r.poll(ctx, tc, sc, &result)
+	} else {
+		r.sendWithRetry(ctx, tc, sc, &result)
+	}
+
+	// 因运行取消而中断的请求不计为失败
+	if ctx.Err() != nil && !result.Passed() {
+		result.Status = model.StatusSkipped
+		result.Error = notRunReason(ctx)
+		return result
+	}
+
+	if !result.Passed() {
+		return result
+	}
+
+	// 响应正确但超过耗时上限同样判定为失败
+	if tc.MaxLatency > 0 && result.ExecutionTime > float64(tc.MaxLatency.Microseconds())/1000 {
+		result.Status = model.StatusFailed
+		result.Error = fmt.Sprintf("响应耗时 %.0fms 超过上限 %s", result.ExecutionTime, tc.MaxLatency)
+		return result
+	}
+
+	// 提取变量供场景后续步骤使用
+	if missing := sc.extract(result.ActualResult, tc.Extract); len(missing) > 0 {
+		result.Status = model.StatusFailed
+		result.Error = fmt.Sprintf("提取变量失败: %s", strings.Join(missing, ", "))
+	}
+
+	return result
+}
+
+// response 是一次请求的响应
+type response struct {
+	statusCode int
+	body       string
+	latency    float64 // 请求耗时（毫秒），不含限流等待时间
+	redirects  []model.Redirect
+}
+
+// send 发送一次请求，返回状态码、响应体和耗时
+func (r *Runner) send(ctx context.Context, tc model.TestCase, sc *scope) (response, error) {
+	req, err := r.buildRequest(ctx, tc)
+	if err != nil {
+		return response{}, fmt.Errorf("创建请求失败: %v", err)
 	}
 
-	// 构建 URL
+	client, err := r.client(req.URL, tc, sc.jar)
+	if err != nil {
+		return response{}, fmt.Errorf("创建请求失败: %v", err)
+	}
+
+	release, err := r.limiter.acquire(ctx, req.URL.Host)
+	if err != nil {
+		return response{}, fmt.Errorf("执行请求失败: %v", err)
+	}
+	defer release()
+
+	var redirects []model.Redirect
+	client.CheckRedirect = checkRedirect(tc.Redirect, &redirects)
+
+	startTime := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return response{latency: sinceMillis(startTime), redirects: redirects}, fmt.Errorf("执行请求失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	// 读取响应
+	body, err := io.ReadAll(resp.Body)
+	res := response{statusCode: resp.StatusCode, body: string(body), latency: sinceMillis(startTime), redirects: redirects}
+	if err != nil {
+		return res, fmt.Errorf("读取响应失败: %v", err)
+	}
+	return res, nil
+}
+
+// timeout 返回用例的请求超时时间，用例未填写时使用全局配置
+func (r *Runner) timeout(tc model.TestCase) time.Duration {
+	if tc.Timeout > 0 {
+		return tc.Timeout
+	}
+	return r.config.Timeout
+}
+
+// sinceMillis 返回从 start 到现在经过的毫秒数
+func sinceMillis(start time.Time) float64 {
+	return float64(time.Since(start).Microseconds()) / 1000
+}
+
+// buildRequest 根据用例构建 HTTP 请求
+func (r *Runner) buildRequest(ctx context.Context, tc model.TestCase) (*http.Request, error) {
+	// 构建 URL，Unix 套接字的请求发往 http://localhost，由 Transport 连接到套接字
 	url := tc.BaseURL + tc.Path
+	if _, ok := unixSocket(tc.BaseURL); ok {
+		url = "http://localhost" + tc.Path
+	}
 	for k, v := range tc.PathParams {
 		url = strings.Replace(url, "{"+k+"}", v, -1)
 	}
@@ -247,12 +457,9 @@ func (r *Runner) executeTest(caseNumber int, tc model.TestCase) model.TestResult
 	}
 
 	// 创建请求
-	req, err := http.NewRequest(tc.Method, url, bytes.NewBufferString(tc.Body))
+	req, err := http.NewRequestWithContext(ctx, tc.Method, url, bytes.NewBufferString(tc.Body))
 	if err != nil {
-		result.Success = false
-		result.Error = fmt.Sprintf("创建请求失败: %v", err)
-		result.Curl = r.toCurl(req, tc.Body) // 使用 toCurl 替代 buildCurlCommand
-		return result
+		return nil, err
 	}
 
 	// 设置请求头
@@ -265,34 +472,7 @@ func (r *Runner) executeTest(caseNumber int, tc model.TestCase) model.TestResult
 		req.Header.Set(k, v)
 	}
 
-	// 生成 curl 命令
-	result.Curl = r.toCurl(req, tc.Body)
-
-	// 执行请求
-	client := &http.Client{Timeout: r.config.Timeout}
-	resp, err := client.Do(req)
-	if err != nil {
-		result.Success = false
-		result.Error = fmt.Sprintf("执行请求失败: %v", err)
-		return result
-	}
-	defer resp.Body.Close()
-
-	// 读取响应
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		result.Success = false
-		result.Error = fmt.Sprintf("读取响应失败: %v", err)
-		return result
-	}
-
-	result.ActualResult = string(body)
-	result.Success = r.validateResponse(result.ActualResult, tc.Expected, tc.StrictMatch)
-
-	// 记录执行时间（毫秒）
-	result.ExecutionTime = float64(time.Since(startTime).Microseconds()) / 1000
-
-	return result
+	return req, nil
 }
 
 func (r *Runner) validateResponse(actual, expected string, strictMatch bool) bool {
@@ -409,7 +589,8 @@ func (r *Runner) validateSlice(actual, expected []interface{}) bool {
 }
 
 // toCurl 将请求转换为 curl 命令
-func (r *Runner) toCurl(req *http.Request, body string) string {
+func (r *Runner) toCurl(req *http.Request, tc model.TestCase) string {
+	body := tc.Body
 	curl := fmt.Sprintf("curl -X %s", req.Method)
 
 	// 添加请求头
@@ -422,6 +603,18 @@ func (r *Runner) toCurl(req *http.Request, body string) string {
 		curl += fmt.Sprintf(" -d '%s'", body)
 	}
 
+	// 添加代理、证书、解析和重定向参数
+	_, socket, target := r.target(req.URL, tc)
+	curl += curlTransportFlags(target, socket)
+	if proxy := proxyFor(target.Proxy, req.URL); proxy != "" {
+		curl += fmt.Sprintf(" -x '%s'", proxy)
+	}
+	curl += curlTLSFlags(target.TLS)
+	if socket == "" {
+		curl += curlResolveFlag(r.config.Resolve, req.URL)
+	}
+	curl += curlRedirectFlags(tc.Redirect)
+
 	// 添加URL
 	curl += fmt.Sprintf(" '%s'", req.URL.String())
 
@@ -455,49 +648,6 @@ func isHTTPMethod(method string) bool {
 	return false
 }
 
-// 添加 findFirstBaseURL 方法
-func (r *Runner) findFirstBaseURL(currentCaseName string) string {
-	f, err := excelize.OpenFile(r.config.ExcelPath)
-	if err != nil {
-		return ""
-	}
-	defer f.Close()
-
-	rows, err := f.GetRows(r.config.SheetName)
-	if err != nil {
-		return ""
-	}
-
-	// 跳过第一行
-	if len(rows) <= 1 {
-		return ""
-	}
-	rows = rows[1:]
-
-	// 找到当前用例的位置
-	currentIndex := -1
-	for i, row := range rows {
-		if len(row) > 0 && row[0] == currentCaseName {
-			currentIndex = i
-			break
-		}
-	}
-
-	if currentIndex == -1 {
-		return ""
-	}
-
-	// 从当前用例向上查找第一个有 base-url 的用例
-	for i := currentIndex; i >= 0; i-- {
-		row := rows[i]
-		if len(row) >= 10 && row[9] != "" {
-			return row[9]
-		}
-	}
-
-	return ""
-}
-
 // 新增：初始化全局配置
 func (r *Runner) initGlobalConfig(rows [][]string) {
 	for _, row := range rows {
@@ -518,3 +668,34 @@ func (r *Runner) initGlobalConfig(rows [][]string) {
 		break
 	}
 }
+
+// isDisabled 判断启用列是否表示禁用，未填写时视为启用
+func isDisabled(value string) bool {
+	switch strings.ToLower(value) {
+	case "false", "0", "no", "n", "否":
+		return true
+	}
+	return false
+}
+
+// parseDuration 解析时长列，eg: 500ms、2s，纯数字按毫秒处理，无法解析时忽略
+func parseDuration(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if ms, err := strconv.Atoi(value); err == nil && ms > 0 {
+		return time.Duration(ms) * time.Millisecond
+	}
+	if d, err := time.ParseDuration(value); err == nil && d > 0 {
+		return d
+	}
+	return 0
+}
+
+// cellAt 返回指定列的值，列不存在时返回空字符串
+func cellAt(row []string, idx int) string {
+	if idx < len(row) {
+		return strings.TrimSpace(row[idx])
+	}
+	return ""
+}
@@ -0,0 +1,216 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"regression_testing/internal/model"
+)
+
+// 变量引用格式: ${name}
+var varPattern = regexp.MustCompile(`\$\{([^}]+)\}`)
+
+// scope 是同一场景内各步骤共享的变量和 Cookie
+type scope struct {
+	vars map[string]string
+	jar  http.CookieJar
+}
+
+// newScope 创建变量作用域，继承 base 中已有的变量
+func newScope(base map[string]string) *scope {
+	jar, _ := cookiejar.New(nil)
+	vars := make(map[string]string, len(base))
+	for k, v := range base {
+		vars[k] = v
+	}
+	return &scope{
+		vars: vars,
+		jar:  jar,
+	}
+}
+
+// runJob 执行一个调度单元；场景中任一步骤失败后，剩余步骤标记为跳过；
+// 分组中的用例各自使用独立的变量作用域，只有依赖了同组中未通过用例的用例才会跳过，
+// 每个用例执行完后通过 progress 通知调度器，依赖它的组外用例无需等待整个分组结束
+func (r *Runner) runJob(ctx context.Context, j job, sr *sheetRun, progress func(caseNum int, results []model.TestResult)) []model.TestResult {
+	sc := newScope(sr.vars)
+	results := make([]model.TestResult, 0, len(j.steps))
+	failed := make(map[int]bool) // 分组中未通过的用例
+	for i, s := range j.steps {
+		if ctx.Err() != nil {
+			results = append(results, skippedResults(j.steps[i:], notRunReason(ctx))...)
+			break
+		}
+		start := len(results)
+		stop := false
+		dep, depFailed := failedDependency(j.steps[:i], s, failed)
+		switch {
+		// 禁用的用例直接跳过，场景中的后续步骤照常执行
+		case s.testCase.Disabled:
+			results = append(results, skippedResults([]step{s}, disabledReason(s.testCase))...)
+			failed[s.caseNum] = true
+		case j.group != "" && depFailed:
+			reason := fmt.Sprintf("前置用例未通过: %s", dep.testCase.CaseName)
+			results = append(results, skippedResults([]step{s}, reason)...)
+			failed[s.caseNum] = true
+		default:
+			if j.group != "" {
+				sc = newScope(sr.vars)
+			}
+			result, hookResults := r.executeWithEachHooks(ctx, s, sr, sc)
+			if !result.Passed() {
+				failed[s.caseNum] = true
+			}
+			result.Step = s.index
+			results = append(results, result)
+			results = append(results, hookResults...)
+			if !result.Passed() && j.scenario != "" {
+				reason := fmt.Sprintf("场景中的前置步骤未通过: %s", s.testCase.CaseName)
+				results = append(results, skippedResults(j.steps[i+1:], reason)...)
+				stop = true
+			}
+		}
+		if j.group != "" && progress != nil {
+			progress(s.caseNum, append([]model.TestResult(nil), results[start:]...))
+		}
+		if stop {
+			break
+		}
+	}
+	return results
+}
+
+// failedDependency 返回 s 所依赖的、同组中排在它前面且未通过的用例
+func failedDependency(before []step, s step, failed map[int]bool) (step, bool) {
+	for _, ref := range s.testCase.DependsOn {
+		for _, prev := range before {
+			if failed[prev.caseNum] && (strconv.Itoa(prev.caseNum) == ref || strings.TrimSpace(prev.testCase.CaseName) == ref) {
+				return prev, true
+			}
+		}
+	}
+	return step{}, false
+}
+
+// disabledReason 返回禁用用例的跳过原因
+func disabledReason(tc model.TestCase) string {
+	if tc.SkipReason != "" {
+		return "已禁用: " + tc.SkipReason
+	}
+	return "已禁用"
+}
+
+// expand 替换字符串中引用的变量，未定义的变量保持原样
+func (s *scope) expand(value string) string {
+	if !strings.Contains(value, "${") {
+		return value
+	}
+	return varPattern.ReplaceAllStringFunc(value, func(match string) string {
+		name := match[2 : len(match)-1]
+		if v, ok := s.vars[name]; ok {
+			return v
+		}
+		return match
+	})
+}
+
+func (s *scope) expandMap(m map[string]string) map[string]string {
+	expanded := make(map[string]string, len(m))
+	for k, v := range m {
+		expanded[k] = s.expand(v)
+	}
+	return expanded
+}
+
+// resolve 返回替换变量后的用例
+func (s *scope) resolve(tc model.TestCase) model.TestCase {
+	tc.Path = s.expand(tc.Path)
+	tc.PathParams = s.expandMap(tc.PathParams)
+	tc.QueryParams = s.expandMap(tc.QueryParams)
+	tc.Body = s.expand(tc.Body)
+	tc.Headers = s.expandMap(tc.Headers)
+	tc.Expected = s.expand(tc.Expected)
+	tc.Token = s.expand(tc.Token)
+	return tc
+}
+
+// extract 按 JSON 路径从响应中提取变量，返回提取失败的变量名
+func (s *scope) extract(body string, rules map[string]string) []string {
+	if len(rules) == 0 {
+		return nil
+	}
+
+	var data interface{}
+	if err := json.Unmarshal([]byte(body), &data); err != nil {
+		data = nil
+	}
+
+	var missing []string
+	for name, path := range rules {
+		value, ok := lookupPath(data, path)
+		if !ok {
+			missing = append(missing, name)
+			continue
+		}
+		s.vars[name] = stringify(value)
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// lookupPath 按点分路径读取 JSON 值，数组使用下标，例如 data.items.0.id
+func lookupPath(data interface{}, path string) (interface{}, bool) {
+	current := data
+	for _, key := range strings.Split(path, ".") {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			v, ok := node[key]
+			if !ok {
+				return nil, false
+			}
+			current = v
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, false
+			}
+			current = node[i]
+		default:
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+func stringify(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case nil:
+		return ""
+	default:
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+}
+
+// parseExtract 解析变量提取列，格式为 {"变量名": "JSON路径"}
+func parseExtract(value string) map[string]string {
+	if value == "" {
+		return nil
+	}
+	var rules map[string]string
+	if err := json.Unmarshal([]byte(value), &rules); err != nil {
+		return nil
+	}
+	return rules
+}
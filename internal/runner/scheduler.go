@@ -0,0 +1,471 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"regression_testing/internal/model"
+)
+
+// errFailureLimit 表示失败用例数达到上限而停止运行
+var errFailureLimit = errors.New("失败用例数达到上限")
+
+// notRunReason 返回运行停止后未执行用例的原因
+func notRunReason(ctx context.Context) string {
+	if errors.Is(context.Cause(ctx), errFailureLimit) {
+		return "未执行: 失败用例数达到上限"
+	}
+	return "未执行: 运行已取消"
+}
+
+// recordFailure 记录一个失败用例，失败数达到上限时停止运行
+func (r *Runner) recordFailure() {
+	limit := r.config.MaxFailures
+	if r.config.FailFast {
+		limit = 1
+	}
+	if limit <= 0 {
+		return
+	}
+	if int(r.failures.Add(1)) >= limit {
+		r.stopRun(errFailureLimit)
+	}
+}
+
+// step 表示一个待执行的测试用例
+type step struct {
+	caseNum  int
+	index    int // 在场景中的步骤序号，普通用例为 0
+	testCase model.TestCase
+}
+
+// job 表示一个调度单元：普通用例只包含一个步骤，场景和分组包含按行顺序串行执行的多个步骤
+type job struct {
+	scenario  string
+	group     string // 分组中的用例互不共享变量，某个用例失败不影响同组的其他用例
+	steps     []step
+	iteration int // 并发重复执行时的副本序号，依赖只在同一副本内查找；普通执行为 0
+}
+
+// jobResult 携带调度单元在列表中的位置，便于回填结果；
+// caseNum 非 0 时表示分组中该行的用例已执行完，results 只包含这个用例的结果
+type jobResult struct {
+	index   int
+	caseNum int
+	results []model.TestResult
+}
+
+// edge 表示一条依赖关系：caseNum 非 0 时只依赖分组中该行的用例，为 0 时依赖整个调度单元
+type edge struct {
+	job      int
+	caseNum  int
+	released bool
+}
+
+// buildJobs 将用例组装为调度单元，同一场景的步骤合并为一个单元
+func buildJobs(steps []step) []job {
+	var jobs []job
+	scenarios := make(map[string]int)
+	for _, s := range steps {
+		name := s.testCase.Scenario
+		if name == "" {
+			jobs = append(jobs, job{steps: []step{s}})
+			continue
+		}
+		if idx, ok := scenarios[name]; ok {
+			s.index = len(jobs[idx].steps) + 1
+			jobs[idx].steps = append(jobs[idx].steps, s)
+			continue
+		}
+		s.index = 1
+		scenarios[name] = len(jobs)
+		jobs = append(jobs, job{scenario: name, steps: []step{s}})
+	}
+	return jobs
+}
+
+// mergeGroups 将同一分组的普通用例合并为一个调度单元，放在该组第一个用例的位置；
+// 场景已经串行执行，其中的用例不参与分组
+func mergeGroups(jobs []job) []job {
+	var merged []job
+	groups := make(map[string]int)
+	for _, j := range jobs {
+		name := j.steps[0].testCase.Group
+		if j.scenario != "" || name == "" {
+			merged = append(merged, j)
+			continue
+		}
+		if idx, ok := groups[name]; ok {
+			merged[idx].steps = append(merged[idx].steps, j.steps...)
+			continue
+		}
+		groups[name] = len(merged)
+		merged = append(merged, job{group: name, steps: j.steps})
+	}
+	return merged
+}
+
+// passed 判断调度单元的所有步骤是否全部通过
+func passed(results []model.TestResult) bool {
+	for _, result := range results {
+		if !result.Passed() {
+			return false
+		}
+	}
+	return true
+}
+
+// schedule 按依赖关系调度用例：依赖全部通过后才会投递给工作协程，
+// 相互独立的用例并发执行，前置用例未通过时依赖它的用例标记为跳过
+func (r *Runner) schedule(ctx context.Context, jobs []job, sr *sheetRun) []model.TestResult {
+	if len(jobs) == 0 {
+		return nil
+	}
+
+	pending := make([]int, len(jobs))       // 尚未完成的依赖数量
+	dependents := make([][]edge, len(jobs)) // 依赖当前用例的用例
+	invalid := make(map[int]string)         // 依赖配置错误的用例
+	order := r.dispatchOrder(len(jobs))     // 派发顺序，结果仍按 jobs 的顺序汇总
+
+	for _, i := range order {
+		j := jobs[i]
+		seen := make(map[edge]bool)
+		for k, s := range j.steps {
+			for _, ref := range s.testCase.DependsOn {
+				d, ok := findCopy(jobs, ref, j.iteration)
+				if !ok {
+					invalid[i] = fmt.Sprintf("依赖用例不存在: %s", ref)
+					continue
+				}
+				if d == i {
+					// 场景和分组内的步骤本就按顺序执行，依赖同一单元的前序步骤无需调度；
+					// 分组中的用例依赖排在后面的同组用例时无法满足，视为配置错误
+					switch {
+					case j.scenario == "" && j.group == "":
+						invalid[i] = "用例不能依赖自身"
+					case j.group != "" && stepIndex(j.steps, ref) < 0:
+						invalid[i] = fmt.Sprintf("用例不能依赖自身所在的分组: %s", ref)
+					case j.group != "" && stepIndex(j.steps, ref) >= k:
+						invalid[i] = fmt.Sprintf("依赖的用例在同一分组中没有排在前面: %s", ref)
+					}
+					continue
+				}
+				// 依赖分组中的某个用例时只看这个用例的结果，依赖分组名称时看整个分组
+				e := edge{job: i}
+				if jobs[d].group != "" {
+					if k := stepIndex(jobs[d].steps, ref); k >= 0 {
+						e.caseNum = jobs[d].steps[k].caseNum
+					}
+				}
+				if seen[e] {
+					continue
+				}
+				seen[e] = true
+				pending[i]++
+				dependents[d] = append(dependents[d], e)
+			}
+		}
+	}
+	graph := make([][]int, len(jobs))
+	for d, es := range dependents {
+		for _, e := range es {
+			graph[d] = append(graph[d], e.job)
+		}
+	}
+	for _, i := range findCycles(pending, graph) {
+		if _, ok := invalid[i]; !ok {
+			invalid[i] = "存在循环依赖"
+		}
+	}
+
+	results := make([][]model.TestResult, len(jobs))
+	done := make([]bool, len(jobs))
+	remaining := len(jobs)
+
+	queue := make(chan int, len(jobs))
+	resultChan := make(chan jobResult, len(jobs))
+	for i := 0; i < r.config.Concurrent; i++ {
+		go r.worker(ctx, jobs, sr, queue, resultChan)
+	}
+
+	// record 记录用例结果，release 释放或跳过依赖它的用例
+	var release func(idx, caseNum int, result []model.TestResult)
+	record := func(idx int, result []model.TestResult) {
+		if it := jobs[idx].iteration; it > 0 {
+			for k := range result {
+				result[k].Iteration = it
+			}
+		}
+		results[idx] = result
+		done[idx] = true
+		remaining--
+	}
+	// release 处理 idx 中尚未释放的依赖关系；caseNum 非 0 时只处理依赖该行用例的关系，
+	// 为 0 时说明整个调度单元已经结束，处理剩余的全部关系
+	release = func(idx, caseNum int, result []model.TestResult) {
+		for k := range dependents[idx] {
+			e := &dependents[idx][k]
+			if e.released || (caseNum != 0 && e.caseNum != caseNum) {
+				continue
+			}
+			e.released = true
+			d := e.job
+			if done[d] {
+				continue
+			}
+			if ctx.Err() != nil {
+				record(d, skippedResults(jobs[d].steps, notRunReason(ctx)))
+				release(d, 0, results[d])
+				continue
+			}
+			name, ok := jobs[idx].name(), passed(result)
+			if e.caseNum != 0 {
+				name, ok = stepName(jobs[idx].steps, e.caseNum), stepPassed(result, e.caseNum)
+			}
+			if !ok {
+				reason := fmt.Sprintf("前置用例未通过: %s", name)
+				record(d, skippedResults(jobs[d].steps, reason))
+				release(d, 0, results[d])
+				continue
+			}
+			pending[d]--
+			if pending[d] == 0 {
+				queue <- d
+			}
+		}
+	}
+
+	// 依赖配置错误的用例先全部记为失败并计入失败数，再处理依赖它们的用例
+	for i := range jobs {
+		if reason, ok := invalid[i]; ok {
+			record(i, failedResults(jobs[i].steps, reason))
+			for _, result := range results[i] {
+				if result.Blocking() {
+					r.recordFailure()
+				}
+			}
+		}
+	}
+	for i := range jobs {
+		if _, ok := invalid[i]; ok {
+			release(i, 0, results[i])
+		}
+	}
+	for _, i := range order {
+		if !done[i] && pending[i] == 0 {
+			queue <- i
+		}
+	}
+
+	for remaining > 0 {
+		res := <-resultChan
+		if res.caseNum != 0 {
+			release(res.index, res.caseNum, res.results)
+			continue
+		}
+		record(res.index, res.results)
+		release(res.index, 0, res.results)
+	}
+	close(queue)
+
+	var flat []model.TestResult
+	for _, rs := range results {
+		flat = append(flat, rs...)
+	}
+	return flat
+}
+
+// name 返回调度单元的展示名称
+func (j job) name() string {
+	if j.scenario != "" {
+		return j.scenario
+	}
+	if j.group != "" {
+		return j.group
+	}
+	return j.steps[0].testCase.CaseName
+}
+
+// findJob 根据用例名称、行号、场景或分组名称查找所在的调度单元
+func findJob(jobs []job, ref string) (int, bool) {
+	return findCopy(jobs, ref, 0)
+}
+
+// findCopy 在第 iteration 份副本的调度单元中查找 ref
+func findCopy(jobs []job, ref string, iteration int) (int, bool) {
+	if num, err := strconv.Atoi(ref); err == nil {
+		for i, j := range jobs {
+			for _, s := range j.steps {
+				if j.iteration == iteration && s.caseNum == num {
+					return i, true
+				}
+			}
+		}
+	}
+	for i, j := range jobs {
+		for _, s := range j.steps {
+			if j.iteration == iteration && strings.TrimSpace(s.testCase.CaseName) == ref {
+				return i, true
+			}
+		}
+	}
+	for i, j := range jobs {
+		if j.iteration == iteration && (j.scenario == ref || j.group == ref) {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+// stepIndex 返回调度单元中行号或用例名称为 ref 的步骤位置，不存在时返回 -1
+func stepIndex(steps []step, ref string) int {
+	for i, s := range steps {
+		if strconv.Itoa(s.caseNum) == ref || strings.TrimSpace(s.testCase.CaseName) == ref {
+			return i
+		}
+	}
+	return -1
+}
+
+// stepName 返回调度单元中第 caseNum 行用例的名称
+func stepName(steps []step, caseNum int) string {
+	for _, s := range steps {
+		if s.caseNum == caseNum {
+			return s.testCase.CaseName
+		}
+	}
+	return strconv.Itoa(caseNum)
+}
+
+// stepPassed 判断第 caseNum 行用例是否已执行且通过，用例级钩子的结果不计入
+func stepPassed(results []model.TestResult, caseNum int) bool {
+	found := false
+	for _, result := range results {
+		if result.Hook != "" || result.CaseNumber != caseNum {
+			continue
+		}
+		if !result.Passed() {
+			return false
+		}
+		found = true
+	}
+	return found
+}
+
+// findCycles 返回处于循环依赖中的用例
+func findCycles(pending []int, dependents [][]int) []int {
+	// 拓扑排序后仍未被访问的用例，要么处于环中，要么依赖环中的用例
+	counts := make([]int, len(pending))
+	copy(counts, pending)
+	var queue []int
+	for i, c := range counts {
+		if c == 0 {
+			queue = append(queue, i)
+		}
+	}
+	visited := make([]bool, len(pending))
+	for len(queue) > 0 {
+		i := queue[0]
+		queue = queue[1:]
+		visited[i] = true
+		for _, d := range dependents[i] {
+			counts[d]--
+			if counts[d] == 0 {
+				queue = append(queue, d)
+			}
+		}
+	}
+
+	// 只有能沿依赖关系回到自身的用例才处于环中
+	var cycles []int
+	for i := range pending {
+		if !visited[i] && reaches(dependents, i, i) {
+			cycles = append(cycles, i)
+		}
+	}
+	return cycles
+}
+
+// reaches 判断从 from 出发能否到达 target
+func reaches(dependents [][]int, from, target int) bool {
+	seen := make(map[int]bool)
+	stack := append([]int(nil), dependents[from]...)
+	for len(stack) > 0 {
+		i := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if i == target {
+			return true
+		}
+		if seen[i] {
+			continue
+		}
+		seen[i] = true
+		stack = append(stack, dependents[i]...)
+	}
+	return false
+}
+
+// parseDependsOn 解析依赖列，支持逗号分隔的用例名称或行号
+func parseDependsOn(value string) []string {
+	return splitList(value)
+}
+
+// splitList 解析逗号或换行分隔的列表
+func splitList(value string) []string {
+	if value == "" {
+		return nil
+	}
+	var items []string
+	for _, item := range strings.FieldsFunc(value, func(c rune) bool {
+		return c == ',' || c == '，' || c == '\n'
+	}) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// newResult 根据用例构建基础结果
+func newResult(s step) model.TestResult {
+	return model.TestResult{
+		CaseNumber:     s.caseNum,
+		CaseName:       s.testCase.CaseName,
+		Method:         s.testCase.Method,
+		Path:           s.testCase.Path,
+		PathParams:     s.testCase.PathParams,
+		QueryParams:    s.testCase.QueryParams,
+		RequestBody:    s.testCase.Body,
+		ExpectedResult: s.testCase.Expected,
+		Scenario:       s.testCase.Scenario,
+		Step:           s.index,
+		Sheet:          s.testCase.Sheet,
+		KnownIssue:     s.testCase.KnownIssue,
+		Quarantine:     s.testCase.Quarantine,
+	}
+}
+
+func skippedResults(steps []step, reason string) []model.TestResult {
+	results := make([]model.TestResult, 0, len(steps))
+	for _, s := range steps {
+		result := newResult(s)
+		result.Status = model.StatusSkipped
+		result.Error = reason
+		results = append(results, result)
+	}
+	return results
+}
+
+func failedResults(steps []step, reason string) []model.TestResult {
+	results := make([]model.TestResult, 0, len(steps))
+	for _, s := range steps {
+		result := newResult(s)
+		result.Status = model.StatusError
+		result.Error = reason
+		results = append(results, result)
+	}
+	return results
+}
@@ -0,0 +1,262 @@
+package runner
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"regression_testing/internal/config"
+	"regression_testing/internal/model"
+)
+
+// testCase 构建测试用的用例，/ok 返回期望结果，/fail 返回不匹配的结果
+type testCase struct {
+	row       int
+	name      string
+	path      string
+	dependsOn []string
+	scenario  string
+	group     string
+}
+
+func buildSteps(baseURL string, cases []testCase) []step {
+	steps := make([]step, 0, len(cases))
+	for _, c := range cases {
+		path := c.path
+		if path == "" {
+			path = "/ok"
+		}
+		steps = append(steps, step{caseNum: c.row, testCase: model.TestCase{
+			CaseName:  c.name,
+			Method:    "GET",
+			Path:      path,
+			Expected:  `{"code":0}`,
+			BaseURL:   baseURL,
+			DependsOn: c.dependsOn,
+			Scenario:  c.scenario,
+			Group:     c.group,
+			Sheet:     "Sheet1",
+		}})
+	}
+	return steps
+}
+
+func newTestServer(t *testing.T) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/fail" {
+			w.Write([]byte(`{"code":1}`))
+			return
+		}
+		w.Write([]byte(`{"code":0}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newTestRunner(cfg *config.Config) *Runner {
+	if cfg.Concurrent == 0 {
+		cfg.Concurrent = 2
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 5 * time.Second
+	}
+	r := New(cfg, "")
+	r.stopRun = func(error) {}
+	return r
+}
+
+func TestFindJob(t *testing.T) {
+	jobs := mergeGroups(buildJobs(buildSteps("", []testCase{
+		{row: 2, name: "登录"},
+		{row: 3, name: "下单", scenario: "购物"},
+		{row: 4, name: "支付", scenario: "购物"},
+		{row: 5, name: "查询", group: "订单"},
+		{row: 6, name: "取消", group: "订单"},
+		{row: 7, name: "8"},
+		{row: 8, name: "退出"},
+	})))
+
+	tests := []struct {
+		ref  string
+		want int
+		ok   bool
+	}{
+		{ref: "2", want: 0, ok: true},
+		{ref: "登录", want: 0, ok: true},
+		{ref: "支付", want: 1, ok: true},
+		{ref: "购物", want: 1, ok: true},
+		{ref: "取消", want: 2, ok: true},
+		{ref: "订单", want: 2, ok: true},
+		{ref: "8", want: 4, ok: true}, // 行号优先于用例名称
+		{ref: "不存在", want: -1, ok: false},
+		{ref: "99", want: -1, ok: false},
+	}
+	for _, tt := range tests {
+		got, ok := findJob(jobs, tt.ref)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("findJob(%q) = %d, %v, want %d, %v", tt.ref, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestFindCycles(t *testing.T) {
+	tests := []struct {
+		name       string
+		dependents [][]int // dependents[i] 为依赖 i 的用例
+		want       []int
+	}{
+		{name: "无依赖", dependents: [][]int{nil, nil}, want: nil},
+		{name: "链式依赖", dependents: [][]int{{1}, {2}, nil}, want: nil},
+		{name: "两个用例互相依赖", dependents: [][]int{{1}, {0}}, want: []int{0, 1}},
+		{name: "依赖环中用例的用例不在环中", dependents: [][]int{{1}, {0, 2}, nil}, want: []int{0, 1}},
+		{name: "三个用例成环", dependents: [][]int{{1}, {2}, {0}, nil}, want: []int{0, 1, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pending := make([]int, len(tt.dependents))
+			for _, ds := range tt.dependents {
+				for _, d := range ds {
+					pending[d]++
+				}
+			}
+			got := findCycles(pending, tt.dependents)
+			sort.Ints(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("findCycles() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSchedule(t *testing.T) {
+	srv := newTestServer(t)
+
+	tests := []struct {
+		name  string
+		cases []testCase
+		want  map[int]model.Status // 行号 -> 状态
+		error map[int]string       // 行号 -> 错误信息
+	}{
+		{
+			name: "前置用例通过后执行",
+			cases: []testCase{
+				{row: 2, name: "a"},
+				{row: 3, name: "b", dependsOn: []string{"a"}},
+				{row: 4, name: "c", dependsOn: []string{"2", "b"}},
+			},
+			want: map[int]model.Status{2: model.StatusPassed, 3: model.StatusPassed, 4: model.StatusPassed},
+		},
+		{
+			name: "前置用例未通过时逐级跳过",
+			cases: []testCase{
+				{row: 2, name: "a", path: "/fail"},
+				{row: 3, name: "b", dependsOn: []string{"a"}},
+				{row: 4, name: "c", dependsOn: []string{"b"}},
+				{row: 5, name: "d"},
+			},
+			want:  map[int]model.Status{2: model.StatusFailed, 3: model.StatusSkipped, 4: model.StatusSkipped, 5: model.StatusPassed},
+			error: map[int]string{3: "前置用例未通过: a", 4: "前置用例未通过: b"},
+		},
+		{
+			name: "依赖配置错误",
+			cases: []testCase{
+				{row: 2, name: "a", dependsOn: []string{"不存在"}},
+				{row: 3, name: "b", dependsOn: []string{"b"}},
+				{row: 4, name: "c", dependsOn: []string{"d"}},
+				{row: 5, name: "d", dependsOn: []string{"c"}},
+				{row: 6, name: "e", dependsOn: []string{"a"}},
+			},
+			want: map[int]model.Status{
+				2: model.StatusError, 3: model.StatusError, 4: model.StatusError, 5: model.StatusError, 6: model.StatusSkipped,
+			},
+			error: map[int]string{2: "依赖用例不存在: 不存在", 3: "用例不能依赖自身", 4: "存在循环依赖", 5: "存在循环依赖"},
+		},
+		{
+			name: "场景中任一步骤失败后跳过剩余步骤",
+			cases: []testCase{
+				{row: 2, name: "a", scenario: "s"},
+				{row: 3, name: "b", scenario: "s", path: "/fail"},
+				{row: 4, name: "c", scenario: "s", dependsOn: []string{"b"}},
+				{row: 5, name: "d", dependsOn: []string{"s"}},
+			},
+			want: map[int]model.Status{2: model.StatusPassed, 3: model.StatusFailed, 4: model.StatusSkipped, 5: model.StatusSkipped},
+		},
+		{
+			name: "分组中的失败只影响依赖它的用例",
+			cases: []testCase{
+				{row: 2, name: "a", group: "g", path: "/fail"},
+				{row: 3, name: "b", group: "g", dependsOn: []string{"a"}},
+				{row: 4, name: "c", group: "g"},
+			},
+			want:  map[int]model.Status{2: model.StatusFailed, 3: model.StatusSkipped, 4: model.StatusPassed},
+			error: map[int]string{3: "前置用例未通过: a"},
+		},
+		{
+			name: "依赖分组中的单个用例只看该用例的结果",
+			cases: []testCase{
+				{row: 2, name: "a", group: "g"},
+				{row: 3, name: "c", group: "g", path: "/fail"},
+				{row: 4, name: "needsA", dependsOn: []string{"a"}},
+				{row: 5, name: "needsC", dependsOn: []string{"3"}},
+				{row: 6, name: "needsG", dependsOn: []string{"g"}},
+			},
+			want: map[int]model.Status{
+				2: model.StatusPassed, 3: model.StatusFailed, 4: model.StatusPassed, 5: model.StatusSkipped, 6: model.StatusSkipped,
+			},
+			error: map[int]string{5: "前置用例未通过: c", 6: "前置用例未通过: g"},
+		},
+		{
+			name: "依赖同组中排在后面的用例",
+			cases: []testCase{
+				{row: 2, name: "a", group: "g", dependsOn: []string{"b"}},
+				{row: 3, name: "b", group: "g"},
+			},
+			want:  map[int]model.Status{2: model.StatusError, 3: model.StatusError},
+			error: map[int]string{2: "依赖的用例在同一分组中没有排在前面: b"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestRunner(&config.Config{})
+			jobs := mergeGroups(buildJobs(buildSteps(srv.URL, tt.cases)))
+			results := r.schedule(context.Background(), jobs, &sheetRun{name: "Sheet1"})
+
+			if len(results) != len(tt.cases) {
+				t.Fatalf("got %d results, want %d", len(results), len(tt.cases))
+			}
+			for _, result := range results {
+				if want := tt.want[result.CaseNumber]; result.Status != want {
+					t.Errorf("row %d: status = %s, want %s (%s)", result.CaseNumber, result.Status, want, result.Error)
+				}
+				if want, ok := tt.error[result.CaseNumber]; ok && result.Error != want {
+					t.Errorf("row %d: error = %q, want %q", result.CaseNumber, result.Error, want)
+				}
+			}
+		})
+	}
+}
+
+func TestScheduleFailFastCountsInvalidDependencies(t *testing.T) {
+	srv := newTestServer(t)
+	r := newTestRunner(&config.Config{FailFast: true, Concurrent: 1})
+	ctx, stop := context.WithCancelCause(context.Background())
+	defer stop(nil)
+	r.stopRun = stop
+
+	jobs := buildJobs(buildSteps(srv.URL, []testCase{
+		{row: 2, name: "a", dependsOn: []string{"不存在"}},
+		{row: 3, name: "b"},
+		{row: 4, name: "c"},
+	}))
+	results := r.schedule(ctx, jobs, &sheetRun{name: "Sheet1"})
+
+	for _, result := range results[1:] {
+		if !result.Skipped() || result.Error != "未执行: 失败用例数达到上限" {
+			t.Errorf("row %d: status = %s (%s), want skipped after fail-fast", result.CaseNumber, result.Status, result.Error)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package runner
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"regression_testing/internal/config"
+)
+
+// rowRange 是闭区间行号范围
+type rowRange struct {
+	from, to int
+}
+
+// selector 按标签、名称、行号和优先级筛选用例
+type selector struct {
+	tags        map[string]bool
+	excludeTags map[string]bool
+	name        *regexp.Regexp
+	rows        []rowRange
+	priorities  map[string]bool
+}
+
+// newSelector 根据筛选条件创建选择器，没有任何条件时返回 nil
+func newSelector(f config.Filter) (*selector, error) {
+	if len(f.Tags) == 0 && len(f.ExcludeTags) == 0 && f.Name == "" && f.Rows == "" && len(f.Priorities) == 0 {
+		return nil, nil
+	}
+
+	s := &selector{
+		tags:        toSet(f.Tags, false),
+		excludeTags: toSet(f.ExcludeTags, false),
+		priorities:  toSet(f.Priorities, true),
+	}
+	if f.Name != "" {
+		re, err := regexp.Compile(f.Name)
+		if err != nil {
+			return nil, fmt.Errorf("用例名称筛选条件无效: %v", err)
+		}
+		s.name = re
+	}
+	rows, err := parseRowRanges(f.Rows)
+	if err != nil {
+		return nil, err
+	}
+	s.rows = rows
+	return s, nil
+}
+
+// match 判断单个用例是否符合筛选条件
+func (s *selector) match(st step) bool {
+	tc := st.testCase
+	if len(s.tags) > 0 && !hasAny(tc.Tags, s.tags) {
+		return false
+	}
+	if hasAny(tc.Tags, s.excludeTags) {
+		return false
+	}
+	if s.name != nil && !s.name.MatchString(tc.CaseName) {
+		return false
+	}
+	if len(s.priorities) > 0 && !s.priorities[tc.Priority] {
+		return false
+	}
+	if len(s.rows) > 0 {
+		for _, rr := range s.rows {
+			if st.caseNum >= rr.from && st.caseNum <= rr.to {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// selectSteps 返回被选中的用例：场景中任一步骤被选中时保留整个场景，
+// 被选中用例依赖的前置用例也会一并保留，依赖分组名称时保留该组的所有用例
+func (s *selector) selectSteps(steps []step) []step {
+	jobs := buildJobs(steps)
+	selected := make([]bool, len(jobs))
+	var queue []int
+	for i, j := range jobs {
+		for _, st := range j.steps {
+			if s.match(st) {
+				selected[i] = true
+				queue = append(queue, i)
+				break
+			}
+		}
+	}
+
+	// 补充依赖的前置用例
+	for len(queue) > 0 {
+		j := jobs[queue[0]]
+		queue = queue[1:]
+		for _, st := range j.steps {
+			for _, ref := range st.testCase.DependsOn {
+				for _, d := range dependencyJobs(jobs, ref) {
+					if !selected[d] {
+						selected[d] = true
+						queue = append(queue, d)
+					}
+				}
+			}
+		}
+	}
+
+	keep := make(map[int]bool)
+	for i, j := range jobs {
+		if selected[i] {
+			for _, st := range j.steps {
+				keep[st.caseNum] = true
+			}
+		}
+	}
+	var result []step
+	for _, st := range steps {
+		if keep[st.caseNum] {
+			result = append(result, st)
+		}
+	}
+	return result
+}
+
+// dependencyJobs 返回 ref 指向的调度单元；分组中的用例在这里各自是一个单元，
+// ref 为分组名称时返回该组的所有用例
+func dependencyJobs(jobs []job, ref string) []int {
+	if d, ok := findJob(jobs, ref); ok {
+		return []int{d}
+	}
+	var members []int
+	for i, j := range jobs {
+		if j.scenario == "" && j.steps[0].testCase.Group == ref {
+			members = append(members, i)
+		}
+	}
+	return members
+}
+
+// parseRowRanges 解析行号范围，eg: 10-40,50
+func parseRowRanges(value string) ([]rowRange, error) {
+	var ranges []rowRange
+	for _, part := range splitList(value) {
+		from, to, isRange := strings.Cut(part, "-")
+		start, err := strconv.Atoi(strings.TrimSpace(from))
+		if err != nil {
+			return nil, fmt.Errorf("行号范围无效: %s", part)
+		}
+		end := start
+		if isRange {
+			if end, err = strconv.Atoi(strings.TrimSpace(to)); err != nil || end < start {
+				return nil, fmt.Errorf("行号范围无效: %s", part)
+			}
+		}
+		ranges = append(ranges, rowRange{from: start, to: end})
+	}
+	return ranges, nil
+}
+
+func toSet(items []string, upper bool) map[string]bool {
+	set := make(map[string]bool, len(items))
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+		if upper {
+			item = strings.ToUpper(item)
+		}
+		if item != "" {
+			set[item] = true
+		}
+	}
+	return set
+}
+
+func hasAny(items []string, set map[string]bool) bool {
+	for _, item := range items {
+		if set[item] {
+			return true
+		}
+	}
+	return false
+}
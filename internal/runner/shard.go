@@ -0,0 +1,84 @@
+package runner
+
+import "regression_testing/internal/config"
+
+// shardSteps 按分片选择用例：同一场景、同一分组、互相依赖的用例属于同一组，
+// 各组按工作表顺序和行号排列后轮流分配给各分片，同一工作簿每次的分配结果相同
+func shardSteps(sheetSteps [][]step, shard config.Shard) [][]step {
+	sharded := make([][]step, len(sheetSteps))
+	next := 0
+	for i, steps := range sheetSteps {
+		selected := make(map[int]bool)
+		for _, group := range groupSteps(steps) {
+			if next%shard.Total == shard.Index-1 {
+				for _, s := range group {
+					selected[s.caseNum] = true
+				}
+			}
+			next++
+		}
+		// 选中的用例保持原来的行顺序，与不分片时的派发顺序一致
+		for _, s := range steps {
+			if selected[s.caseNum] {
+				sharded[i] = append(sharded[i], s)
+			}
+		}
+	}
+	return sharded
+}
+
+func countSteps(sheetSteps [][]step) int {
+	n := 0
+	for _, steps := range sheetSteps {
+		n += len(steps)
+	}
+	return n
+}
+
+// groupSteps 将场景、分组和依赖关系连通的用例分为一组，组按首个用例的行号排列，组内保持行顺序
+func groupSteps(steps []step) [][]step {
+	jobs := mergeGroups(buildJobs(steps))
+
+	// 并查集合并存在依赖关系的调度单元
+	parent := make([]int, len(jobs))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	for i, j := range jobs {
+		for _, s := range j.steps {
+			for _, ref := range s.testCase.DependsOn {
+				if d, ok := findJob(jobs, ref); ok {
+					parent[find(d)] = find(i)
+				}
+			}
+		}
+	}
+
+	groupOf := make(map[int]int) // 行号 -> 组
+	var groups [][]step
+	index := make(map[int]int) // 并查集根 -> 组下标
+	for i, j := range jobs {
+		root := find(i)
+		g, ok := index[root]
+		if !ok {
+			g = len(groups)
+			index[root] = g
+			groups = append(groups, nil)
+		}
+		for _, s := range j.steps {
+			groupOf[s.caseNum] = g
+		}
+	}
+	for _, s := range steps {
+		g := groupOf[s.caseNum]
+		groups[g] = append(groups[g], s)
+	}
+	return groups
+}
@@ -0,0 +1,138 @@
+package runner
+
+import (
+	"reflect"
+	"testing"
+
+	"regression_testing/internal/config"
+)
+
+// caseNums 返回各组用例的行号
+func caseNums(groups [][]step) [][]int {
+	nums := make([][]int, len(groups))
+	for i, g := range groups {
+		for _, s := range g {
+			nums[i] = append(nums[i], s.caseNum)
+		}
+	}
+	return nums
+}
+
+func TestGroupSteps(t *testing.T) {
+	tests := []struct {
+		name  string
+		cases []testCase
+		want  [][]int
+	}{
+		{
+			name:  "互不相关的用例各自成组",
+			cases: []testCase{{row: 2, name: "a"}, {row: 3, name: "b"}, {row: 4, name: "c"}},
+			want:  [][]int{{2}, {3}, {4}},
+		},
+		{
+			name: "同一场景的用例在同一组",
+			cases: []testCase{
+				{row: 2, name: "a", scenario: "s"}, {row: 3, name: "b"}, {row: 4, name: "c", scenario: "s"},
+			},
+			want: [][]int{{2, 4}, {3}},
+		},
+		{
+			name: "同一分组的用例在同一组",
+			cases: []testCase{
+				{row: 2, name: "a"}, {row: 3, name: "b", group: "g"}, {row: 4, name: "c"}, {row: 5, name: "d", group: "g"},
+			},
+			want: [][]int{{2}, {3, 5}, {4}},
+		},
+		{
+			name: "依赖关系连通的用例在同一组，组内保持行顺序",
+			cases: []testCase{
+				{row: 2, name: "a"},
+				{row: 3, name: "b", dependsOn: []string{"d"}},
+				{row: 4, name: "c"},
+				{row: 5, name: "d", dependsOn: []string{"a"}},
+				{row: 6, name: "e", dependsOn: []string{"s"}},
+				{row: 7, name: "f", scenario: "s"},
+			},
+			want: [][]int{{2, 3, 5}, {4}, {6, 7}},
+		},
+		{
+			name:  "依赖不存在的用例时单独成组",
+			cases: []testCase{{row: 2, name: "a", dependsOn: []string{"不存在"}}, {row: 3, name: "b"}},
+			want:  [][]int{{2}, {3}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := caseNums(groupSteps(buildSteps("", tt.cases)))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("groupSteps() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShardSteps(t *testing.T) {
+	sheets := [][]step{
+		buildSteps("", []testCase{
+			{row: 2, name: "a"},
+			{row: 3, name: "b", scenario: "s"},
+			{row: 4, name: "c", scenario: "s"},
+			{row: 5, name: "d", dependsOn: []string{"a"}},
+		}),
+		buildSteps("", []testCase{
+			{row: 2, name: "e"},
+			{row: 3, name: "f", group: "g"},
+			{row: 4, name: "g", group: "g"},
+		}),
+	}
+
+	// 各组依次为 {a,d} {b,c} {e} {f,g}，按工作表顺序轮流分配
+	tests := []struct {
+		shard config.Shard
+		want  [][]int
+	}{
+		{shard: config.Shard{Index: 1, Total: 1}, want: [][]int{{2, 3, 4, 5}, {2, 3, 4}}},
+		{shard: config.Shard{Index: 1, Total: 2}, want: [][]int{{2, 5}, {2}}},
+		{shard: config.Shard{Index: 2, Total: 2}, want: [][]int{{3, 4}, {3, 4}}},
+		{shard: config.Shard{Index: 1, Total: 3}, want: [][]int{{2, 5}, {3, 4}}},
+		{shard: config.Shard{Index: 3, Total: 3}, want: [][]int{nil, {2}}},
+		{shard: config.Shard{Index: 5, Total: 5}, want: [][]int{nil, nil}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.shard.String(), func(t *testing.T) {
+			got := caseNums(shardSteps(sheets, tt.shard))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("shardSteps(%s) = %v, want %v", tt.shard, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShardStepsCoversEveryCaseOnce(t *testing.T) {
+	var cases []testCase
+	for row := 2; row < 30; row++ {
+		tc := testCase{row: row, name: string(rune('a' + row))}
+		if row%5 == 0 {
+			tc.scenario = "s"
+		}
+		if row%7 == 0 {
+			tc.group = "g"
+		}
+		cases = append(cases, tc)
+	}
+	sheets := [][]step{buildSteps("", cases)}
+
+	for total := 1; total <= 6; total++ {
+		seen := make(map[int]int)
+		for index := 1; index <= total; index++ {
+			for _, s := range shardSteps(sheets, config.Shard{Index: index, Total: total})[0] {
+				seen[s.caseNum]++
+			}
+		}
+		for _, tc := range cases {
+			if seen[tc.row] != 1 {
+				t.Errorf("total %d: row %d assigned %d times", total, tc.row, seen[tc.row])
+			}
+		}
+	}
+}
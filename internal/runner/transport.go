@@ -0,0 +1,265 @@
+package runner
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/http2"
+
+	"regression_testing/internal/config"
+	"regression_testing/internal/model"
+)
+
+// Unix 套接字 base-url 的前缀，请求以 http://localhost 的形式发往套接字
+const unixScheme = "unix://"
+
+// transportPool 为每个被测服务维护一个共享的 Transport，
+// 同一服务的请求复用连接，耗时不再包含建立连接的时间
+type transportPool struct {
+	mu         sync.Mutex
+	transports map[string]http.RoundTripper // 连接目标（见 endpoint） -> Transport
+	errs       map[string]error             // 创建失败的服务，避免每个请求重复读取证书
+}
+
+// client 返回发送用例请求的客户端；Cookie 按场景隔离，所以客户端按请求创建，只共享 Transport
+func (r *Runner) client(u *url.URL, tc model.TestCase, jar http.CookieJar) (*http.Client, error) {
+	t, err := r.transport(u, tc)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{
+		Transport: t,
+		Timeout:   r.timeout(tc),
+		Jar:       jar,
+	}, nil
+}
+
+// transport 返回请求所属服务的 Transport，首次使用时按配置创建
+func (r *Runner) transport(u *url.URL, tc model.TestCase) (http.RoundTripper, error) {
+	key, socket, target := r.target(u, tc)
+
+	p := &r.transports
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if t, ok := p.transports[key]; ok {
+		return t, nil
+	}
+	if err, ok := p.errs[key]; ok {
+		return nil, err
+	}
+	if p.transports == nil {
+		p.transports = make(map[string]http.RoundTripper)
+		p.errs = make(map[string]error)
+	}
+	t, err := newTransport(target, r.config.Resolve, socket)
+	if err != nil {
+		p.errs[key] = err
+		return nil, err
+	}
+	p.transports[key] = t
+	return t, nil
+}
+
+// target 返回请求的连接目标及其生效的配置
+func (r *Runner) target(u *url.URL, tc model.TestCase) (key, socket string, target config.Target) {
+	key, socket = endpoint(u, tc)
+	target = r.config.Target(key)
+	if u.Scheme != "https" {
+		target.TLS = config.TLS{} // 证书配置只对 HTTPS 生效，配置有误时不影响 HTTP 服务
+	} else {
+		target.Transport.H2C = false // h2c 只用于明文 HTTP
+	}
+	if socket != "" || target.Transport.H2C {
+		target.Proxy = config.Proxy{} // Unix 套接字和 h2c 直接连接，不走配置的代理
+	}
+	if target.Transport.MaxIdleConnsPerHost == 0 {
+		// 每个主机的空闲连接数与并发数一致，避免高并发时反复建立连接
+		target.Transport.MaxIdleConnsPerHost = max(r.concurrency(), 2)
+	}
+	return key, socket, target
+}
+
+// endpoint 返回请求的连接目标，eg: http://host:port；
+// base-url 为 Unix 套接字时返回 unix:///path.sock 和套接字路径
+func endpoint(u *url.URL, tc model.TestCase) (key, socket string) {
+	if socket, ok := unixSocket(tc.BaseURL); ok {
+		return tc.BaseURL, socket
+	}
+	return u.Scheme + "://" + u.Host, ""
+}
+
+// unixSocket 解析 unix:///var/run/app.sock 形式的 base-url，返回套接字路径
+func unixSocket(baseURL string) (string, bool) {
+	socket, ok := strings.CutPrefix(baseURL, unixScheme)
+	return socket, ok && socket != ""
+}
+
+// closeTransports 关闭所有空闲连接
+func (r *Runner) closeTransports() {
+	r.transports.mu.Lock()
+	defer r.transports.mu.Unlock()
+	for _, t := range r.transports.transports {
+		if c, ok := t.(interface{ CloseIdleConnections() }); ok {
+			c.CloseIdleConnections()
+		}
+	}
+}
+
+// newTransport 创建 Transport；socket 不为空时所有连接都发往该 Unix 套接字，
+// 配置 h2c 时使用明文 HTTP/2（代理不生效）
+func newTransport(target config.Target, resolve map[string]string, socket string) (http.RoundTripper, error) {
+	tlsConfig, err := newTLSConfig(target.TLS)
+	if err != nil {
+		return nil, err
+	}
+	proxy, err := proxyFunc(target.Proxy)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := target.Transport
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: cfg.KeepAlive,
+	}
+	dial := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if socket != "" {
+			return dialer.DialContext(ctx, "unix", socket)
+		}
+		if pinned, ok := resolveAddr(resolve, addr); ok {
+			addr = pinned
+		}
+		return dialer.DialContext(ctx, network, addr)
+	}
+
+	if cfg.H2C {
+		return &http2.Transport{
+			AllowHTTP: true,
+			DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+				return dial(ctx, network, addr)
+			},
+			IdleConnTimeout: cfg.IdleConnTimeout,
+		}, nil
+	}
+
+	return &http.Transport{
+		Proxy:                 proxy,
+		DialContext:           dial,
+		TLSClientConfig:       tlsConfig,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          cfg.MaxIdleConns,
+		MaxIdleConnsPerHost:   cfg.MaxIdleConnsPerHost,
+		MaxConnsPerHost:       cfg.MaxConnsPerHost,
+		IdleConnTimeout:       cfg.IdleConnTimeout,
+		DisableKeepAlives:     cfg.DisableKeepAlives,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}, nil
+}
+
+// resolveAddr 返回 resolve 中为 host:port 固定的地址，只填写主机名的配置对所有端口生效，
+// host:port 的配置优先；固定的地址只填写 IP 时沿用原端口
+func resolveAddr(resolve map[string]string, addr string) (string, bool) {
+	host, port, _ := net.SplitHostPort(addr)
+	pinned, ok := resolve[addr]
+	if !ok {
+		pinned, ok = resolve[host]
+	}
+	if !ok {
+		return "", false
+	}
+	if _, _, err := net.SplitHostPort(pinned); err == nil {
+		return pinned, true
+	}
+	return net.JoinHostPort(pinned, port), true
+}
+
+// newTLSConfig 根据配置加载根证书和客户端证书，未配置时返回 nil 使用默认设置
+func newTLSConfig(cfg config.TLS) (*tls.Config, error) {
+	if cfg == (config.TLS{}) {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{
+		ServerName:         cfg.ServerName,
+		InsecureSkipVerify: cfg.Insecure,
+	}
+
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("无法读取 CA 证书: %v", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("CA 证书中没有有效的 PEM 证书: %s", cfg.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if cfg.CertFile != "" || cfg.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("无法加载客户端证书: %v", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// curlTransportFlags 返回与 Unix 套接字和 h2c 对应的 curl 参数
+func curlTransportFlags(target config.Target, socket string) string {
+	var flags string
+	if socket != "" {
+		flags += fmt.Sprintf(" --unix-socket '%s'", socket)
+	}
+	if target.Transport.H2C {
+		flags += " --http2-prior-knowledge"
+	}
+	return flags
+}
+
+// curlResolveFlag 返回与 resolve 配置对应的 curl --connect-to 参数
+func curlResolveFlag(resolve map[string]string, u *url.URL) string {
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+	}
+	addr := net.JoinHostPort(u.Hostname(), port)
+	pinned, ok := resolveAddr(resolve, addr)
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf(" --connect-to '%s:%s'", addr, pinned)
+}
+
+// curlTLSFlags 返回与证书配置对应的 curl 参数
+func curlTLSFlags(cfg config.TLS) string {
+	var flags string
+	if cfg.Insecure {
+		flags += " -k"
+	}
+	if cfg.CAFile != "" {
+		flags += fmt.Sprintf(" --cacert '%s'", cfg.CAFile)
+	}
+	if cfg.CertFile != "" {
+		flags += fmt.Sprintf(" --cert '%s' --key '%s'", cfg.CertFile, cfg.KeyFile)
+	}
+	return flags
+}
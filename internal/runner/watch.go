@@ -0,0 +1,161 @@
+package runner
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+
+	"regression_testing/internal/config"
+)
+
+// Snapshot 是工作簿中用例表和钩子表的内容，监听模式据此判断哪些用例被修改
+type Snapshot struct {
+	sheets    map[string][][]string // 工作表 -> 每行的单元格，下标 0 为表头
+	firstCase string                // 首个用例的内容，全局 token 和 GlobalHeaders 取自该行
+}
+
+// ReadSnapshot 读取配置中的用例表和钩子表
+func ReadSnapshot(cfg *config.Config) (*Snapshot, error) {
+	f, err := excelize.OpenFile(cfg.ExcelPath)
+	if err != nil {
+		return nil, fmt.Errorf("无法打开Excel文件: %v", err)
+	}
+	defer f.Close()
+
+	s := &Snapshot{sheets: make(map[string][][]string)}
+	names := append([]string{cfg.HooksSheet}, cfg.Sheets...)
+	for _, name := range names {
+		if idx, _ := f.GetSheetIndex(name); idx == -1 {
+			continue
+		}
+		rows, err := f.GetRows(name)
+		if err != nil {
+			return nil, fmt.Errorf("无法读取工作表 %s: %v", name, err)
+		}
+		for i, row := range rows {
+			if name != cfg.HooksSheet && i > 0 && s.firstCase == "" && len(row) >= 2 && isHTTPMethod(row[1]) {
+				s.firstCase = rowText(row)
+			}
+		}
+		s.sheets[name] = rows
+	}
+	return s, nil
+}
+
+// Changes 返回相对 prev 需要重新执行的工作表；只有一个工作表中的部分行受影响时，
+// rows 为这些行的行号（eg: 5,9），否则为空表示执行整个工作表。
+// 钩子表或首个用例被修改时，所有工作表都需要重新执行
+func (s *Snapshot) Changes(prev *Snapshot, sheets []string) (changed []string, rows string) {
+	if prev == nil || s.firstCase != prev.firstCase {
+		return sheets, ""
+	}
+	for name, cur := range s.sheets {
+		if !slices.Contains(sheets, name) && !equalRows(cur, prev.sheets[name]) {
+			return sheets, "" // 钩子表被修改
+		}
+	}
+
+	var affected []string
+	whole := false // 表头被修改时列的含义可能变化，执行整个工作表
+	for _, name := range sheets {
+		cur, old := s.sheets[name], prev.sheets[name]
+		if equalRows(cur, old) {
+			continue
+		}
+		changed = append(changed, name)
+		if len(cur) == 0 || len(old) == 0 || rowText(cur[0]) != rowText(old[0]) {
+			whole = true
+			continue
+		}
+		for _, i := range affectedRows(cur, old) {
+			affected = append(affected, strconv.Itoa(i+1))
+		}
+	}
+	if len(changed) != 1 || whole {
+		return changed, ""
+	}
+	return changed, strings.Join(affected, ",")
+}
+
+// affectedRows 返回受修改影响的行下标：内容被修改的行、沿用的 base-url 随之变化的行，
+// 以及直接或间接依赖这些行的行
+func affectedRows(cur, old [][]string) []int {
+	n := max(len(cur), len(old))
+	curBase, oldBase := effectiveBaseURLs(cur, n), effectiveBaseURLs(old, n)
+	affected := make([]bool, n)
+	for i := 1; i < n; i++ {
+		affected[i] = rowText(rowAt(cur, i)) != rowText(rowAt(old, i)) || curBase[i] != oldBase[i]
+	}
+
+	// refersTo 判断依赖列中的引用是否指向受影响的行，按修改前后的行号、用例名称、场景和分组匹配
+	refersTo := func(ref string) bool {
+		for j := 1; j < n; j++ {
+			if !affected[j] {
+				continue
+			}
+			if ref == strconv.Itoa(j+1) {
+				return true
+			}
+			for _, row := range [][]string{rowAt(cur, j), rowAt(old, j)} {
+				for _, col := range []int{0, colScenario, colGroup} {
+					if v := cellAt(row, col); v != "" && v == ref {
+						return true
+					}
+				}
+			}
+		}
+		return false
+	}
+	for grew := true; grew; {
+		grew = false
+		for i := 1; i < len(cur); i++ {
+			if affected[i] {
+				continue
+			}
+			if slices.ContainsFunc(parseDependsOn(cellAt(cur[i], colDependsOn)), refersTo) {
+				affected[i] = true
+				grew = true
+			}
+		}
+	}
+
+	var rows []int
+	for i, ok := range affected {
+		if ok {
+			rows = append(rows, i)
+		}
+	}
+	return rows
+}
+
+// effectiveBaseURLs 返回每行实际使用的 base-url，未填写时与 parseSheet 一样沿用上方最近一行的值
+func effectiveBaseURLs(rows [][]string, n int) []string {
+	bases := make([]string, n)
+	inherited := ""
+	for i := 1; i < n; i++ {
+		if baseURL := cellAt(rowAt(rows, i), 9); baseURL != "" {
+			inherited = baseURL
+		}
+		bases[i] = inherited
+	}
+	return bases
+}
+
+func equalRows(a, b [][]string) bool {
+	return slices.EqualFunc(a, b, func(x, y []string) bool { return rowText(x) == rowText(y) })
+}
+
+func rowAt(rows [][]string, i int) []string {
+	if i < len(rows) {
+		return rows[i]
+	}
+	return nil
+}
+
+// rowText 将一行的单元格拼接为一个字符串，用于比较行内容
+func rowText(row []string) string {
+	return strings.Join(row, "\x1f")
+}
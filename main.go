@@ -1,40 +1,227 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 	"time"
 
 	"regression_testing/internal/config"
+	"regression_testing/internal/model"
 	"regression_testing/internal/reporter"
 	"regression_testing/internal/runner"
 )
 
 func main() {
-	cfg, err := config.Load()
+	if len(os.Args) > 1 && os.Args[1] == "merge" {
+		mergeResults(os.Args[2:])
+		return
+	}
+
+	failFast := flag.Bool("fail-fast", false, "首个用例失败后停止执行")
+	maxFailures := flag.Int("max-failures", 0, "失败用例数达到 N 后停止执行")
+	var tags, excludeTags, priorities listFlag
+	flag.Var(&tags, "tag", "只执行包含该标签的用例，可重复或用逗号分隔")
+	flag.Var(&excludeTags, "exclude-tag", "排除包含该标签的用例，可重复或用逗号分隔")
+	flag.Var(&priorities, "priority", "只执行该优先级的用例，eg: P0")
+	name := flag.String("name", "", "只执行名称匹配该正则的用例")
+	rows := flag.String("rows", "", "只执行该行号范围内的用例，eg: 10-40")
+	shard := flag.String("shard", "", "只执行第 i 个分片的用例，eg: 1/3")
+	jsonPath := flag.String("json", "", "将结果写入 JSON 文件，多个分片的结果可以用 merge 命令合并")
+	repeat := flag.Int("repeat", 0, "将选中的用例重复执行 N 次，统计通过率、耗时波动和响应是否一致")
+	repeatConcurrent := flag.Bool("repeat-concurrent", false, "重复执行时将 N 份用例一起并发执行，而不是逐次执行")
+	shuffle := flag.Bool("shuffle", false, "打乱用例的执行顺序")
+	seed := flag.Int64("seed", 0, "打乱顺序的随机种子，用于复现")
+	loadDuration := flag.Duration("load-duration", 0, "压测模式：在该时长内循环发送选中的用例，eg: 30s")
+	loadRPS := flag.Float64("load-rps", 0, "压测的目标每秒请求数（所有用例合计），不填时不限制速率")
+	loadConcurrency := flag.Int("load-concurrency", 0, "压测的并发数，不填时使用 concurrent")
+	dryRun := flag.Bool("dry-run", false, "只解析用例并输出最终的请求和 curl 命令，不发送请求")
+	watch := flag.Bool("watch", false, "监听工作簿和 config.json，保存后自动重新执行修改过的用例")
+	flag.Parse()
+
+	// loadConfig 加载配置文件，命令行参数覆盖配置文件；监听模式下每次配置变化都会重新加载
+	loadConfig := func() (*config.Config, error) {
+		cfg, err := config.Load()
+		if err != nil {
+			return nil, fmt.Errorf("加载配置失败: %v", err)
+		}
+		if *failFast {
+			cfg.FailFast = true
+		}
+		if *maxFailures > 0 {
+			cfg.MaxFailures = *maxFailures
+		}
+		if len(tags) > 0 {
+			cfg.Filter.Tags = tags
+		}
+		if len(excludeTags) > 0 {
+			cfg.Filter.ExcludeTags = excludeTags
+		}
+		if len(priorities) > 0 {
+			cfg.Filter.Priorities = priorities
+		}
+		if *name != "" {
+			cfg.Filter.Name = *name
+		}
+		if *rows != "" {
+			cfg.Filter.Rows = *rows
+		}
+		cfg.LoadTest = config.LoadTest{Duration: *loadDuration, RPS: *loadRPS, Concurrency: *loadConcurrency}
+		cfg.Repeat = *repeat
+		cfg.RepeatConcurrent = *repeatConcurrent
+		cfg.Shuffle = *shuffle || *seed != 0
+		cfg.Seed = *seed
+		if *shard != "" {
+			if cfg.Shard, err = config.ParseShard(*shard); err != nil {
+				return nil, err
+			}
+		}
+		return cfg, nil
+	}
+	cfg, err := loadConfig()
 	if err != nil {
-		log.Fatalf("加载配置失败: %v", err)
+		log.Fatal(err)
+	}
+
+	// Ctrl-C 或 CI 超时时取消运行，已完成的结果仍会写入报告；再次中断则直接退出
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	if *watch {
+		watchCases(ctx, cfg, loadConfig)
+		return
 	}
 
 	r := runner.New(cfg, "")
+	if *dryRun {
+		runDryRun(cfg, r)
+		return
+	}
+	if cfg.LoadTest.Enabled() {
+		runLoad(ctx, cfg, r)
+		return
+	}
 
 	startTime := time.Now()
-	results, err := r.Run()
+	results, err := r.Run(ctx)
 	if err != nil {
 		log.Fatalf("执行测试失败: %v", err)
 	}
 
 	duration := time.Since(startTime)
 	rep := reporter.New(cfg)
-	if err := rep.GenerateReport(results, duration); err != nil {
+	if err := rep.GenerateReport(results, r.CleanupResults(), duration); err != nil {
+		log.Fatalf("生成报告失败: %v", err)
+	}
+	if *jsonPath != "" {
+		run := reporter.RunResults{Duration: duration, Results: results, Cleanups: r.CleanupResults()}
+		if cfg.Shard.Enabled() {
+			run.Shard = cfg.Shard.String()
+		}
+		if err := reporter.WriteJSON(*jsonPath, run); err != nil {
+			log.Fatalf("写入 JSON 结果失败: %v", err)
+		}
+	}
+
+	printOutcome(results, ctx.Err() != nil)
+}
+
+// runLoad 执行压测并生成压测报告
+func runLoad(ctx context.Context, cfg *config.Config, r *runner.Runner) {
+	startTime := time.Now()
+	results, err := r.RunLoad(ctx)
+	if err != nil {
+		log.Fatalf("压测失败: %v", err)
+	}
+	if err := reporter.New(cfg).GenerateLoadReport(results, r.CleanupResults(), time.Since(startTime)); err != nil {
+		log.Fatalf("生成报告失败: %v", err)
+	}
+}
+
+// runDryRun 输出解析后的请求并写入请求预览工作表，不发送请求
+func runDryRun(cfg *config.Config, r *runner.Runner) {
+	previews, err := r.DryRun()
+	if err != nil {
+		log.Fatalf("解析用例失败: %v", err)
+	}
+	if err := reporter.New(cfg).GenerateDryRunReport(previews); err != nil {
+		log.Fatalf("生成报告失败: %v", err)
+	}
+}
+
+// mergeResults 合并各分片写入的 JSON 结果，生成一份完整的报告
+func mergeResults(args []string) {
+	fs := flag.NewFlagSet("merge", flag.ExitOnError)
+	jsonPath := fs.String("json", "", "将合并后的结果写入 JSON 文件")
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		log.Fatal("用法: merge [--json merged.json] shard1.json shard2.json ...")
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("加载配置失败: %v", err)
+	}
+
+	runs := make([]reporter.RunResults, 0, fs.NArg())
+	for _, path := range fs.Args() {
+		run, err := reporter.ReadJSON(path)
+		if err != nil {
+			log.Fatalf("读取结果失败: %v", err)
+		}
+		runs = append(runs, run)
+	}
+	merged := reporter.Merge(runs, cfg.Sheets)
+
+	rep := reporter.New(cfg)
+	if err := rep.GenerateReport(merged.Results, merged.Cleanups, merged.Duration); err != nil {
 		log.Fatalf("生成报告失败: %v", err)
 	}
+	if *jsonPath != "" {
+		if err := reporter.WriteJSON(*jsonPath, merged); err != nil {
+			log.Fatalf("写入 JSON 结果失败: %v", err)
+		}
+	}
+
+	printOutcome(merged.Results, false)
+}
 
+// printOutcome 输出整体结果，已知问题和隔离用例的失败不计入
+func printOutcome(results []model.TestResult, cancelled bool) {
 	for _, result := range results {
-		if !result.Success {
+		if result.Blocking() {
 			fmt.Println("测试失败")
 			return
 		}
 	}
+	if cancelled {
+		fmt.Println("测试已取消")
+		return
+	}
 	fmt.Println("测试通过")
 }
+
+// listFlag 是可以重复指定、也可以用逗号分隔的命令行参数
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(value string) error {
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*l = append(*l, item)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"regression_testing/internal/config"
+	"regression_testing/internal/model"
+	"regression_testing/internal/runner"
+)
+
+const (
+	watchInterval = time.Second            // 检查文件修改时间的间隔
+	watchSettle   = 300 * time.Millisecond // 发现修改后等待文件写完再读取
+)
+
+// caseKey 标识工作表中的一个用例
+type caseKey struct {
+	sheet string
+	row   int
+}
+
+// watcher 记录上次执行时的文件修改时间、工作簿内容和每个用例最近一次的结果
+type watcher struct {
+	loadConfig func() (*config.Config, error)
+	excelPath  string
+	configTime time.Time
+	excelTime  time.Time
+	snapshot   *runner.Snapshot
+	results    map[caseKey]model.TestResult
+}
+
+// watchCases 先执行一次所有选中的用例，之后每当工作簿或 config.json 被保存时，
+// 只重新执行修改过的工作表或行，并输出测试结果的变化；监听模式不写入测试报告
+func watchCases(ctx context.Context, cfg *config.Config, loadConfig func() (*config.Config, error)) {
+	w := &watcher{loadConfig: loadConfig, results: make(map[caseKey]model.TestResult)}
+	w.stat(cfg.ExcelPath)
+	snapshot, err := runner.ReadSnapshot(cfg)
+	if err != nil {
+		fmt.Printf("\033[31m读取工作簿失败: %v\033[0m\n", err)
+	}
+	w.snapshot = snapshot
+	w.run(ctx, cfg, cfg.Sheets, "", true)
+
+	fmt.Printf("\n正在监听 %s 和 config.json，保存后自动重新执行，按 Ctrl-C 退出\n", cfg.ExcelPath)
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if modTime("config.json").Equal(w.configTime) && modTime(w.excelPath).Equal(w.excelTime) {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(watchSettle):
+		}
+		w.reload(ctx)
+	}
+}
+
+// reload 重新读取配置和工作簿，执行受影响的用例；文件有误时等待下一次保存
+func (w *watcher) reload(ctx context.Context) {
+	configChanged := !modTime("config.json").Equal(w.configTime)
+	cfg, err := w.loadConfig()
+	if err != nil {
+		w.stat(w.excelPath)
+		fmt.Printf("\033[31m%v\033[0m\n", err)
+		return
+	}
+	w.stat(cfg.ExcelPath)
+	snapshot, err := runner.ReadSnapshot(cfg)
+	if err != nil {
+		fmt.Printf("\033[31m读取工作簿失败: %v\033[0m\n", err)
+		return
+	}
+
+	prev := w.snapshot
+	if configChanged {
+		prev = nil // 配置变化可能影响所有用例
+	}
+	w.snapshot = snapshot
+	sheets, rows := snapshot.Changes(prev, cfg.Sheets)
+	if len(sheets) == 0 {
+		return
+	}
+	// 已经通过 --rows 限定行号时，按工作表重新执行
+	if cfg.Filter.Rows != "" {
+		rows = ""
+	}
+
+	fmt.Printf("\n\033[36m[%s] 检测到修改，重新执行: %s\033[0m\n", time.Now().Format("15:04:05"), describeChanges(sheets, rows))
+	cfg.Sheets = sheets
+	if rows != "" {
+		cfg.Filter.Rows = rows
+	}
+	w.run(ctx, cfg, sheets, rows, false)
+}
+
+// run 执行用例并输出与上一次相比的结果变化
+func (w *watcher) run(ctx context.Context, cfg *config.Config, sheets []string, rows string, initial bool) {
+	results, err := runner.New(cfg, "").Run(ctx)
+	// 按行重新执行时没有找到用例，说明修改过的行都已不是用例，按删除处理
+	if err != nil && rows == "" {
+		fmt.Printf("\033[31m执行测试失败: %v\033[0m\n", err)
+		return
+	}
+	if ctx.Err() != nil {
+		return
+	}
+
+	current := make(map[caseKey]bool, len(results))
+	changes := 0
+	for _, result := range results {
+		key := caseKey{sheet: result.Sheet, row: result.CaseNumber}
+		current[key] = true
+		old, ok := w.results[key]
+		w.results[key] = result
+		switch {
+		case initial:
+			if result.Blocking() {
+				printChange(result, "", result.Status.Label())
+				changes++
+			}
+		case !ok:
+			printChange(result, "新增", result.Status.Label())
+			changes++
+		case old.Status != result.Status:
+			printChange(result, old.Status.Label(), result.Status.Label())
+			changes++
+		}
+	}
+
+	// 重新执行的范围内不再存在的用例视为已删除；
+	// 每个用例前后的钩子只在失败时输出结果，没有结果不代表被删除，只清除上次的记录
+	selected := strings.Split(rows, ",")
+	for key, old := range w.results {
+		if current[key] {
+			continue
+		}
+		if old.Hook != "" {
+			delete(w.results, key)
+			continue
+		}
+		if !slices.Contains(sheets, key.sheet) || (rows != "" && !slices.Contains(selected, strconv.Itoa(key.row))) {
+			continue
+		}
+		delete(w.results, key)
+		if !initial {
+			fmt.Printf("用例 %d %s [%s]: 已删除\n", key.row, old.CaseName, key.sheet)
+			changes++
+		}
+	}
+
+	if changes == 0 && !initial {
+		fmt.Println("测试结果没有变化")
+	}
+	printWatchSummary(results)
+}
+
+// printWatchSummary 输出本次执行的汇总；已知问题和隔离用例的失败与 printOutcome 一样不计入失败
+func printWatchSummary(results []model.TestResult) {
+	failed, skipped := 0, 0
+	for _, result := range results {
+		if result.Blocking() {
+			failed++
+		} else if result.Skipped() {
+			skipped++
+		}
+	}
+	line := fmt.Sprintf("本次执行 %d 个用例，失败 %d，跳过 %d", len(results), failed, skipped)
+	if failed > 0 {
+		fmt.Printf("\033[31m%s\033[0m\n", line)
+	} else {
+		fmt.Println(line)
+	}
+}
+
+// stat 记录工作簿和 config.json 当前的修改时间
+func (w *watcher) stat(excelPath string) {
+	w.excelPath = excelPath
+	w.configTime = modTime("config.json")
+	w.excelTime = modTime(excelPath)
+}
+
+// printChange 输出单个用例的结果变化，通过标绿，失败和错误标红
+func printChange(result model.TestResult, from, to string) {
+	line := fmt.Sprintf("用例 %d %s [%s]: %s", result.CaseNumber, result.CaseName, result.Sheet, to)
+	if from != "" {
+		line = fmt.Sprintf("用例 %d %s [%s]: %s → %s", result.CaseNumber, result.CaseName, result.Sheet, from, to)
+	}
+	if result.Error != "" && !result.Passed() {
+		line += " (" + result.Error + ")"
+	}
+	switch {
+	case result.Passed():
+		fmt.Printf("\033[32m%s\033[0m\n", line)
+	case result.Failed():
+		fmt.Printf("\033[31m%s\033[0m\n", line)
+	default:
+		fmt.Println(line)
+	}
+}
+
+// describeChanges 描述重新执行的范围，eg: Sheet1 第 5,9 行
+func describeChanges(sheets []string, rows string) string {
+	if rows != "" {
+		return fmt.Sprintf("%s 第 %s 行", sheets[0], rows)
+	}
+	return strings.Join(sheets, ", ")
+}
+
+// modTime 返回文件的修改时间，文件不存在时返回零值
+func modTime(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}